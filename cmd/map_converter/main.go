@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		inputFile    string
+		outputFile   string
+		inputFormat  string
+		outputFormat string
+		allowLoss    bool
+	)
+
+	formats := strings.Join(invasion.CodecNames(), ", ")
+	flag.StringVar(&inputFile, "in", "", "Map file to convert. Gzip compressed files are supported.")
+	flag.StringVar(&outputFile, "out", "", "Output file where the converted map will be written. Files ending with .gz are gzip compressed.")
+	flag.StringVar(&inputFormat, "from", "", "Format of the input file: "+formats+". Ignoring this, the format is detected by the file extension or content.")
+	flag.StringVar(&outputFormat, "to", "", "Format of the output file: "+formats+". Ignoring this, the format is chosen by the file extension.")
+	flag.BoolVar(&allowLoss, "allow-loss", false, "Convert the map even if the output format cannot hold all its data (e.g. the attributes in the csv format).")
+	flag.Parse()
+
+	if inputFile == "" || outputFile == "" {
+		log.Fatalln("Both -in and -out files must be specified")
+	}
+
+	// check if the file already exists
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Fatalf("The file %q already exists.", outputFile)
+	}
+
+	worldMap, err := invasion.ReadWorldMapFile(inputFile, inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	codec, err := invasion.CodecForFile(outputFile, outputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if codec.Lost != nil {
+		if lost := codec.Lost(worldMap); len(lost) > 0 {
+			msg := fmt.Sprintf("Converting to the %s format would lose %s", codec.Name, strings.Join(lost, ", "))
+			if !allowLoss {
+				log.Fatalf("%s. Use -allow-loss to convert it anyway.", msg)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+		}
+	}
+	if err := invasion.WriteWorldMapFile(outputFile, outputFormat, worldMap); err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("Created %s file\n", outputFile)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		mapFormat string
+		dotFile   string
+	)
+
+	flag.StringVar(&mapFormat, "format", "", "Format of the map files: "+strings.Join(invasion.CodecNames(), ", ")+". Ignoring this, the format is detected by the file extension or content.")
+	flag.StringVar(&dotFile, "dot", "", "Optional Graphviz DOT file where both maps will be written with the differences highlighted.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of map_diff: map_diff [flags] <before> <after>\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "The after file can also be a simulator result, then its result map is compared.\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "The exit status is 1 if the maps are different.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	before, err := readMap(flag.Arg(0), mapFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	after, err := readMap(flag.Arg(1), mapFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	d := invasion.DiffMaps(before, after)
+	if err := invasion.WriteDiff(os.Stdout, d); err != nil {
+		log.Fatalln(err)
+	}
+
+	if dotFile != "" {
+		buf := &bytes.Buffer{}
+		if err := invasion.EncodeDiffDOT(buf, before, after); err != nil {
+			log.Fatalln(err)
+		}
+		if err := os.WriteFile(dotFile, buf.Bytes(), os.ModePerm); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("File %q was created successfully.\n", dotFile)
+	}
+
+	if !d.Empty() {
+		os.Exit(1)
+	}
+}
+
+// readMap reads a map file or the result map of a simulator result file.
+//
+func readMap(fname, format string) (*invasion.WorldMap, error) {
+	f, err := invasion.OpenMapFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if invasion.IsResult(data) {
+		return invasion.ReadResultMap(bytes.NewReader(data))
+	}
+	codec, err := invasion.DetectCodec(fname, format, data)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(bytes.NewReader(data))
+}
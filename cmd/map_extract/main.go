@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		mapFile      string
+		inputFormat  string
+		outputFile   string
+		outputFormat string
+		around       string
+		hops         int
+		region       string
+		cities       string
+	)
+
+	formats := strings.Join(invasion.CodecNames(), ", ")
+	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file to extract the cities from. Gzip compressed files are supported.")
+	flag.StringVar(&inputFormat, "from", "", "Format of the world map file: "+formats+". Ignoring this, the format is detected by the file extension or content.")
+	flag.StringVar(&outputFile, "out", "", "Output file where the extracted map will be written. Ignoring this, the map will be printed in STDOUT.")
+	flag.StringVar(&outputFormat, "to", "", "Format of the output file: "+formats+". Ignoring this, the format is chosen by the file extension.")
+	flag.StringVar(&around, "around", "", "Extract the cities at most -k roads away from this city.")
+	flag.IntVar(&hops, "k", 1, "Number of roads used with -around.")
+	flag.StringVar(&region, "region", "", "Extract the cities inside a rectangle of the inferred layout, written as minX,minY,maxX,maxY (Y grows to the south).")
+	flag.StringVar(&cities, "cities", "", "Extract a comma separated list of cities.")
+	flag.Parse()
+
+	modes := 0
+	for _, v := range []string{around, region, cities} {
+		if v != "" {
+			modes++
+		}
+	}
+	if modes != 1 {
+		log.Fatalln("Exactly one of -around, -region or -cities must be specified")
+	}
+
+	worldMap, err := invasion.ReadWorldMapFile(mapFile, inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var sub *invasion.WorldMap
+	switch {
+	case around != "":
+		sub, err = worldMap.Neighbourhood(around, hops)
+	case region != "":
+		var box invasion.BoundingBox
+		box, err = parseRegion(region)
+		if err == nil {
+			sub = worldMap.Region(box)
+		}
+	default:
+		sub, err = worldMap.Subgraph(strings.Split(cities, ","))
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if outputFile == "" {
+		codec, err := invasion.CodecForFile("", outputFormat)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := codec.Encode(os.Stdout, sub); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	// check if the file already exists
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Fatalf("The file %q already exists.", outputFile)
+	}
+	if err := invasion.WriteWorldMapFile(outputFile, outputFormat, sub); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("Created %s file with %d cities\n", outputFile, sub.NumCities())
+}
+
+// parseRegion parses a rectangle written as minX,minY,maxX,maxY.
+//
+func parseRegion(s string) (invasion.BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return invasion.BoundingBox{}, fmt.Errorf("invalid region %q, it should be minX,minY,maxX,maxY", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return invasion.BoundingBox{}, fmt.Errorf("invalid region %q, it should be minX,minY,maxX,maxY", s)
+		}
+		v[i] = n
+	}
+	return invasion.BoundingBox{
+		Min: invasion.Point{X: v[0], Y: v[1]},
+		Max: invasion.Point{X: v[2], Y: v[3]},
+	}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		check bool
+		write bool
+	)
+
+	flag.BoolVar(&check, "check", false, "Do not write the formatted maps, list the files that are not formatted and exit with status 1 if there is any.")
+	flag.BoolVar(&write, "w", false, "Write the formatted map back to its file instead of STDOUT.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of map_formatter: map_formatter [flags] [files]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Without files, the map is read from STDIN.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	failed := false
+	if flag.NArg() == 0 {
+		if write {
+			fmt.Fprintln(os.Stderr, "Cannot use -w with STDIN")
+			os.Exit(2)
+		}
+		failed = !formatFile("<stdin>", os.Stdin, check, false)
+	}
+	for _, fname := range flag.Args() {
+		f, err := os.Open(fname)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		if !formatFile(fname, f, check, write) {
+			failed = true
+		}
+		f.Close()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// formatFile formats a map file. It returns false if the file could not be
+// formatted or, in check mode, if it is not formatted.
+func formatFile(fname string, r io.Reader, check, write bool) bool {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fname, err)
+		return false
+	}
+	out, err := invasion.FormatMap(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fname, err)
+		return false
+	}
+
+	switch {
+	case check:
+		if !bytes.Equal(src, out) {
+			fmt.Println(fname)
+			return false
+		}
+	case write:
+		if !bytes.Equal(src, out) {
+			if err := os.WriteFile(fname, out, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", fname, err)
+				return false
+			}
+		}
+	default:
+		os.Stdout.Write(out)
+	}
+	return true
+}
@@ -1,26 +1,84 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/fpabl0/saga-alien-invasion/invasion"
 	"github.com/fpabl0/saga-alien-invasion/mapgen"
 )
 
 func main() {
 	var (
-		outputFile string
-		width      int
-		height     int
+		outputFile    string
+		width         int
+		height        int
+		format        string
+		seed          int64
+		roadRemoval   float64
+		cityRemoval   float64
+		keepConnected bool
+		maze          string
+		loops         float64
+		wrap          string
+		genType       string
+		islands       int
+		landRatio     float64
+		crossLinks    float64
+		branches      float64
+		armLength     int
+		hubLinks      float64
+		maskFile      string
 	)
 
-	flag.StringVar(&outputFile, "out", "", "Output file where the generated map will be written. Ignoring this, the generated map will be printed in STDOUT.")
+	flag.StringVar(&outputFile, "out", "", "Output file where the generated map will be written. Ignoring this, the generated map will be printed in STDOUT. Files ending with .gz are gzip compressed.")
+	flag.StringVar(&genType, "type", "grid", "Type of the generated map: "+strings.Join(mapgen.TypeNames(), ", ")+". The flags that the type does not use are rejected.")
 	flag.IntVar(&width, "width", 20, "The width of the map.")
 	flag.IntVar(&height, "height", 20, "The height of the map.")
+	flag.StringVar(&format, "format", "", "Format of the generated map: "+strings.Join(invasion.CodecNames(), ", ")+". Ignoring this, the format is chosen by the output file extension.")
+	flag.Int64Var(&seed, "seed", 0, "Seed used by the random choices of the generator. Ignoring this, the current time is used. The seed used is written to STDERR.")
+	flag.Float64Var(&roadRemoval, "road-removal", 0, "Probability (0-1) of removing each road of the grid.")
+	flag.Float64Var(&cityRemoval, "city-removal", 0, "Probability (0-1) of removing each city of the grid.")
+	flag.BoolVar(&keepConnected, "keep-connected", false, "Restore some of the removed roads and cities, so the map is connected.")
+	flag.StringVar(&maze, "maze", "none", "Carve a perfect maze in the grid with an algorithm: none, backtracker, prim or kruskal. It cannot be used with -road-removal.")
+	flag.Float64Var(&loops, "loops", 0, "Probability (0-1) of restoring each road removed by the maze, making loops. It needs -maze.")
+	flag.StringVar(&wrap, "wrap", "none", "Join the edges of the grid: none, cylinder (east to west), torus (east to west and south to north) or mobius (east to west with the rows flipped).")
+	flag.IntVar(&islands, "islands", 3, "Number of islands of the islands maps.")
+	flag.Float64Var(&landRatio, "land-ratio", 0.4, "Ratio (0-1) of the grid covered by the islands of the islands maps.")
+	flag.Float64Var(&crossLinks, "cross-links", 0, "Probability (0-1) of adding each road of the grid missing in the spiral maps.")
+	flag.Float64Var(&branches, "branches", 0, "Probability (0-1) of adding a city at the north and at the south of each city of the line maps.")
+	flag.IntVar(&armLength, "arm-length", 2, "Number of cities of each arm of the hubs of the hubs maps.")
+	flag.Float64Var(&hubLinks, "hub-links", 1, "Probability (0-1) of joining each pair of neighbouring hubs of the hubs maps.")
+	flag.StringVar(&maskFile, "mask", "", "File with the mask of the mask maps: one line per row, with '.' or ' ' for the cells without city.")
 	flag.Parse()
 
+	if width < 0 || height < 0 {
+		log.Fatalln("The width and the height cannot be negative")
+	}
+	mazeAlg, err := mapgen.ParseMazeAlgorithm(maze)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	wrapMode, err := mapgen.ParseWrap(wrap)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// only the passed flags become options, so the generator type can
+	// reject the ones it does not use; the seed can be 0, so it is only
+	// chosen if its flag was not passed
+	passed := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	if !passed["seed"] {
+		seed = time.Now().UnixNano()
+	}
+
 	if outputFile != "" {
 		// check if the file already exists
 		_, err := os.Stat(outputFile)
@@ -29,13 +87,83 @@ func main() {
 		}
 	}
 
-	g := mapgen.NewGenerator(width, height)
+	codec, err := invasion.CodecForFile(outputFile, format)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	opts := []mapgen.Option{mapgen.WithSeed(seed)}
+	flagOpts := []struct {
+		name string
+		opt  mapgen.Option
+	}{
+		{"road-removal", mapgen.WithRoadRemoval(roadRemoval)},
+		{"city-removal", mapgen.WithCityRemoval(cityRemoval)},
+		{"maze", mapgen.WithMaze(mazeAlg)},
+		{"loops", mapgen.WithLoops(loops)},
+		{"wrap", mapgen.WithWrap(wrapMode)},
+		{"islands", mapgen.WithIslands(islands)},
+		{"land-ratio", mapgen.WithLandRatio(landRatio)},
+		{"cross-links", mapgen.WithCrossLinks(crossLinks)},
+		{"branches", mapgen.WithBranches(branches)},
+		{"arm-length", mapgen.WithArmLength(armLength)},
+		{"hub-links", mapgen.WithHubLinks(hubLinks)},
+	}
+	for _, fo := range flagOpts {
+		if passed[fo.name] {
+			opts = append(opts, fo.opt)
+		}
+	}
+	if keepConnected {
+		opts = append(opts, mapgen.WithKeepConnected())
+	}
+	if maskFile != "" {
+		f, err := os.Open(maskFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		mask, err := mapgen.ReadMask(f)
+		f.Close()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		opts = append(opts, mapgen.WithMask(mask))
+	} else if genType == "mask" {
+		log.Fatalln("The mask maps need a mask file")
+	}
+	g, err := mapgen.New(genType, width, height, opts...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// the seed is reported so the map can be generated again
+	fmt.Fprintf(os.Stderr, "Seed: %d\n", seed)
 	data := g.Generate()
 
+	// the generated map is in the text format, other formats need to
+	// parse it first
+	if codec.Name != "text" {
+		worldMap, err := invasion.ReadWorldMap(bytes.NewReader(data))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		buf := &bytes.Buffer{}
+		if err := codec.Encode(buf, worldMap); err != nil {
+			log.Fatalln(err)
+		}
+		data = buf.Bytes()
+	}
+
 	if outputFile == "" {
-		fmt.Println(string(data))
+		os.Stdout.Write(data)
 	} else {
-		if err := os.WriteFile(outputFile, data, os.ModePerm); err != nil {
+		f, err := invasion.CreateMapFile(outputFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			log.Fatalln(err)
+		}
+		if err := f.Close(); err != nil {
 			log.Fatalln(err)
 		}
 
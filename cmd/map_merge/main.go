@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		inputFormat  string
+		outputFile   string
+		outputFormat string
+		prefixes     string
+		collisions   string
+		stitch       string
+	)
+
+	formats := strings.Join(invasion.CodecNames(), ", ")
+	flag.StringVar(&inputFormat, "from", "", "Format of the world map files: "+formats+". Ignoring this, the format is detected by the file extension or content.")
+	flag.StringVar(&outputFile, "out", "", "Output file where the merged map will be written. Ignoring this, the map will be printed in STDOUT.")
+	flag.StringVar(&outputFormat, "to", "", "Format of the output file: "+formats+". Ignoring this, the format is chosen by the file extension.")
+	flag.StringVar(&prefixes, "prefixes", "", "Comma separated list of prefixes added to the city names of each map, in the same order as the files.")
+	flag.StringVar(&collisions, "collisions", "error", "What to do with a city name found in more than one map: error, merge (they are the same city) or rename (add the suffix _N, where N is the number of the map).")
+	flag.StringVar(&stitch, "stitch", "", "Join each map to the given side (north, south, east or west) of the previous ones, connecting their edges by the inferred layout. Ignoring this, the maps are not joined.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of map_merge: map_merge [flags] <map>...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatalln("At least one world map file must be specified")
+	}
+
+	policy, err := invasion.ParseCollisionPolicy(collisions)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	opts := []invasion.MergeOption{invasion.WithCollisionPolicy(policy)}
+	if prefixes != "" {
+		opts = append(opts, invasion.WithPrefixes(strings.Split(prefixes, ",")...))
+	}
+
+	maps := make([]*invasion.WorldMap, 0, flag.NArg())
+	for _, fname := range flag.Args() {
+		m, err := invasion.ReadWorldMapFile(fname, inputFormat)
+		if err != nil {
+			log.Fatalf("%s: %v", fname, err)
+		}
+		maps = append(maps, m)
+	}
+
+	var merged *invasion.WorldMap
+	if stitch == "" {
+		merged, err = invasion.MergeMaps(maps, opts...)
+	} else {
+		var side invasion.Direction
+		side, err = invasion.ParseDirection(stitch)
+		if err == nil {
+			merged, err = invasion.StitchMaps(maps, side, opts...)
+		}
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if outputFile == "" {
+		codec, err := invasion.CodecForFile("", outputFormat)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := codec.Encode(os.Stdout, merged); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	// check if the file already exists
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Fatalf("The file %q already exists.", outputFile)
+	}
+	if err := invasion.WriteWorldMapFile(outputFile, outputFormat, merged); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("Created %s file with %d cities\n", outputFile, merged.NumCities())
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+const usage = `Usage of map_query: map_query [flags] <query> [cities]
+
+Queries:
+  path <from> <to>      Directions of the shortest path between two cities.
+  distance <from> <to>  Number of roads of the shortest path between two cities.
+  distances [city]      Distances from a city to every reachable city, or
+                        between every pair of connected cities.
+  eccentricity <city>   Greatest distance from a city to any reachable city.
+  diameter              Greatest distance between two connected cities.
+  articulation          Cities whose destruction splits the map.
+  bridges               Roads whose removal splits the map.
+  critical              Cities whose destruction splits the map, ranked by
+                        the number of cities they disconnect.
+
+Flags:
+`
+
+func main() {
+	var (
+		mapFile   string
+		mapFormat string
+	)
+
+	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file to query. Gzip compressed files are supported.")
+	flag.StringVar(&mapFormat, "format", "", "Format of the world map file: "+strings.Join(invasion.CodecNames(), ", ")+". Ignoring this, the format is detected by the file extension or content.")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	query, args := flag.Arg(0), flag.Args()[1:]
+
+	worldMap, err := invasion.ReadWorldMapFile(mapFile, mapFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	switch query {
+	case "path":
+		checkArgs(query, args, 2)
+		path, err := worldMap.ShortestPath(args[0], args[1])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		dirs := make([]string, 0, len(path))
+		for _, d := range path {
+			dirs = append(dirs, d.String())
+		}
+		fmt.Println(strings.Join(dirs, " "))
+	case "distance":
+		checkArgs(query, args, 2)
+		d, err := worldMap.Distance(args[0], args[1])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(d)
+	case "distances":
+		if len(args) > 1 {
+			log.Fatalln("The distances query needs at most 1 city")
+		}
+		if len(args) == 1 {
+			dists := worldMap.Distances(args[0])
+			if dists == nil {
+				log.Fatalf("city %s does not exist", args[0])
+			}
+			printDistances(args[0], dists)
+			return
+		}
+		all := worldMap.AllPairsDistances()
+		for _, from := range worldMap.Cities() {
+			printDistances(from, all[from])
+		}
+	case "eccentricity":
+		checkArgs(query, args, 1)
+		ecc, err := worldMap.Eccentricity(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(ecc)
+	case "diameter":
+		checkArgs(query, args, 0)
+		fmt.Println(worldMap.Diameter())
+	case "articulation":
+		checkArgs(query, args, 0)
+		for _, name := range worldMap.ArticulationPoints() {
+			fmt.Println(name)
+		}
+	case "bridges":
+		checkArgs(query, args, 0)
+		for _, r := range worldMap.Bridges() {
+			fmt.Println(r)
+		}
+	case "critical":
+		checkArgs(query, args, 0)
+		for i, cc := range worldMap.CriticalCities() {
+			fmt.Printf("%d. %s (disconnected cities: %d, parts: %d)\n", i+1, cc.Name, cc.Disconnected, cc.Parts)
+		}
+	default:
+		log.Fatalf("Unknown query %q", query)
+	}
+}
+
+// checkArgs exits if the query does not have the expected number of cities.
+//
+func checkArgs(query string, args []string, n int) {
+	if len(args) != n {
+		log.Fatalf("The %s query needs %d cities", query, n)
+	}
+}
+
+// printDistances prints the distances from a city, one per line, sorted
+// by city name.
+func printDistances(from string, dists map[string]int) {
+	names := make([]string, 0, len(dists))
+	for name := range dists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, to := range names {
+		fmt.Printf("%s %s %d\n", from, to, dists[to])
+	}
+}
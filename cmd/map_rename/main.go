@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		mapFile      string
+		inputFormat  string
+		outputFile   string
+		outputFormat string
+		mappingFile  string
+		regex        string
+		replacement  string
+		anonymize    string
+		seed         int64
+		saveMapping  string
+		translate    string
+		reverse      bool
+	)
+
+	formats := strings.Join(invasion.CodecNames(), ", ")
+	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file whose cities will be renamed. Gzip compressed files are supported.")
+	flag.StringVar(&inputFormat, "from", "", "Format of the world map file: "+formats+". Ignoring this, the format is detected by the file extension or content.")
+	flag.StringVar(&outputFile, "out", "", "Output file where the renamed map (or the translated result) will be written. Ignoring this, it will be printed in STDOUT.")
+	flag.StringVar(&outputFormat, "to", "", "Format of the output file: "+formats+". Ignoring this, the format is chosen by the file extension.")
+	flag.StringVar(&mappingFile, "mapping", "", "Rename the cities with a mapping file, with the current and the new name of a city per line.")
+	flag.StringVar(&regex, "regex", "", "Rename the cities matching this regular expression, replacing the matches with -replace.")
+	flag.StringVar(&replacement, "replace", "", "Replacement used with -regex. $1, $2... are replaced by the submatches.")
+	flag.StringVar(&anonymize, "anonymize", "", "Rename all the cities to this prefix followed by a shuffled number.")
+	flag.Int64Var(&seed, "seed", 0, "Seed used to shuffle the numbers of -anonymize. Ignoring this, the current time is used. The seed used is written to STDERR.")
+	flag.StringVar(&saveMapping, "save-mapping", "", "Optional file where the mapping used will be written, so the names can be restored later.")
+	flag.StringVar(&translate, "translate", "", "Rename the cities of a simulator result file with the -mapping file, instead of renaming a map.")
+	flag.BoolVar(&reverse, "reverse", false, "Use the -mapping file in reverse, restoring the original names.")
+	flag.Parse()
+
+	if translate != "" {
+		translateResult(translate, mappingFile, reverse, outputFile)
+		return
+	}
+
+	modes := 0
+	for _, v := range []string{mappingFile, regex, anonymize} {
+		if v != "" {
+			modes++
+		}
+	}
+	if modes != 1 {
+		log.Fatalln("Exactly one of -mapping, -regex or -anonymize must be specified")
+	}
+
+	worldMap, err := invasion.ReadWorldMapFile(mapFile, inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var mapping invasion.CityMapping
+	switch {
+	case mappingFile != "":
+		mapping = readMapping(mappingFile, reverse)
+	case regex != "":
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		mapping = worldMap.RegexpMapping(re, replacement)
+	default:
+		// an explicit -seed 0 is kept, the current time is only chosen if
+		// the flag was not passed
+		seedPassed := false
+		flag.Visit(func(f *flag.Flag) {
+			seedPassed = seedPassed || f.Name == "seed"
+		})
+		if !seedPassed {
+			seed = time.Now().UnixNano()
+		}
+		// the seed is reported so the names can be shuffled again
+		fmt.Fprintf(os.Stderr, "Seed: %d\n", seed)
+		mapping = worldMap.AnonymousMapping(anonymize, seed)
+	}
+
+	renamed, err := worldMap.Rename(mapping)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if saveMapping != "" {
+		buf := &bytes.Buffer{}
+		if err := invasion.WriteCityMapping(buf, mapping); err != nil {
+			log.Fatalln(err)
+		}
+		if err := os.WriteFile(saveMapping, buf.Bytes(), os.ModePerm); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if outputFile == "" {
+		codec, err := invasion.CodecForFile("", outputFormat)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := codec.Encode(os.Stdout, renamed); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	// check if the file already exists
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Fatalf("The file %q already exists.", outputFile)
+	}
+	if err := invasion.WriteWorldMapFile(outputFile, outputFormat, renamed); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("Created %s file with %d cities\n", outputFile, renamed.NumCities())
+}
+
+// translateResult renames the cities of a simulator result file.
+//
+func translateResult(fname, mappingFile string, reverse bool, outputFile string) {
+	if mappingFile == "" {
+		log.Fatalln("-translate needs a -mapping file")
+	}
+	mapping := readMapping(mappingFile, reverse)
+
+	f, err := os.Open(fname)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	if outputFile == "" {
+		if err := invasion.TranslateResult(f, os.Stdout, mapping); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := invasion.TranslateResult(f, buf, mapping); err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.WriteFile(outputFile, buf.Bytes(), os.ModePerm); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("File %q was created successfully.\n", outputFile)
+}
+
+// readMapping reads a mapping file, inverting it if reverse is true.
+//
+func readMapping(fname string, reverse bool) invasion.CityMapping {
+	f, err := os.Open(fname)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	mapping, err := invasion.ReadCityMapping(f)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if reverse {
+		mapping, err = mapping.Inverse()
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	return mapping
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+)
+
+func main() {
+	var (
+		mapFile    string
+		mapFormat  string
+		jsonOutput bool
+		noDiameter bool
+	)
+
+	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file. Gzip compressed files are supported.")
+	flag.StringVar(&mapFormat, "format", "", "Format of the world map file: "+strings.Join(invasion.CodecNames(), ", ")+". Ignoring this, the format is detected by the file extension or content.")
+	flag.BoolVar(&jsonOutput, "json", false, "Print the statistics as JSON.")
+	flag.BoolVar(&noDiameter, "no-diameter", false, "Skip the diameter, which is slow for huge maps.")
+	flag.Parse()
+
+	worldMap, err := invasion.ReadWorldMapFile(mapFile, mapFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var opts []invasion.StatsOption
+	if noDiameter {
+		opts = append(opts, invasion.WithoutDiameter())
+	}
+	stats := worldMap.Stats(opts...)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	printStats(os.Stdout, stats)
+}
+
+// printStats prints the map statistics as text.
+//
+func printStats(w io.Writer, s *invasion.MapStats) {
+	fmt.Fprintf(w, "Cities: %d\n", s.Cities)
+	fmt.Fprintf(w, "Roads: %d\n", s.Roads)
+	fmt.Fprintln(w, "Degree histogram:")
+	for degree, n := range s.DegreeHistogram {
+		fmt.Fprintf(w, "  %d neighbours: %d\n", degree, n)
+	}
+	fmt.Fprintf(w, "Dead ends: %d\n", s.DeadEnds)
+
+	sizes := make([]string, 0, len(s.Components))
+	for _, size := range s.Components {
+		sizes = append(sizes, fmt.Sprint(size))
+	}
+	fmt.Fprintf(w, "Connected components: %d", len(s.Components))
+	if len(sizes) > 0 {
+		fmt.Fprintf(w, " (sizes: %s)", strings.Join(sizes, ", "))
+	}
+	fmt.Fprintln(w)
+
+	if s.Diameter != nil {
+		fmt.Fprintf(w, "Diameter: %d\n", *s.Diameter)
+	}
+	if s.Embeddable {
+		fmt.Fprintln(w, "Embeddable in the plane: yes")
+	} else {
+		fmt.Fprintln(w, "Embeddable in the plane: no")
+	}
+	if b := s.BoundingBox; b != nil {
+		fmt.Fprintf(w, "Layout bounding box: %s to %s (%dx%d)\n", b.Min, b.Max, b.Width(), b.Height())
+	}
+}
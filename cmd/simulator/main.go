@@ -1,13 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/fpabl0/saga-alien-invasion/invasion"
 )
@@ -18,16 +18,33 @@ func main() {
 		numOfAliens int
 		mapFile     string
 		outputFile  string
+		duplicates  string
+		dotFile     string
+		graphmlFile string
+		mapFormat   string
+		maxLineLen  int
+		progress    bool
+		order       string
 	)
 
 	flag.IntVar(&numOfAliens, "n", 10, "Specify the number of aliens for the invasion.")
-	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file used for the invasion.")
+	flag.StringVar(&mapFile, "m", "invasion/testdata/small_map.txt", "Specify the world map file used for the invasion. Gzip compressed files are supported.")
+	flag.StringVar(&mapFormat, "format", "", "Format of the world map file: "+strings.Join(invasion.CodecNames(), ", ")+". Ignoring this, the format is detected by the file extension or content.")
+	flag.IntVar(&maxLineLen, "max-line-length", invasion.DefaultMaxLineLength, "Maximum length in bytes of a line of the world map file, greater than 0. Values over 1GB are lowered to 1GB.")
+	flag.BoolVar(&progress, "progress", false, "Report the progress of reading the world map file in STDERR.")
 	flag.StringVar(&outputFile, "o", "", "Output file where the simulator result will be written. Ignoring this, the result will be redirected to STDOUT.")
+	flag.StringVar(&order, "order", "name", "Order of the cities in the result map: name, natural, file or coordinates.")
+	flag.StringVar(&duplicates, "duplicates", "merge", "How a city defined in more than one line is handled: merge, error or last-wins.")
+	flag.StringVar(&dotFile, "dot", "", "Optional Graphviz DOT file where the world map will be written with the invasion result overlaid.")
+	flag.StringVar(&graphmlFile, "graphml", "", "Optional GraphML file where the world map will be written with the invasion result.")
 	flag.Parse()
 
 	if numOfAliens <= 0 {
 		log.Fatalln("The number of aliens must be greater than 0")
 	}
+	if maxLineLen <= 0 {
+		log.Fatalln("The maximum line length must be greater than 0")
+	}
 
 	var out io.Writer
 	if outputFile == "" {
@@ -38,12 +55,33 @@ func main() {
 		out = buf
 	}
 
-	worldMap, err := parseWorldMapFile(mapFile)
+	dupPolicy, err := invasion.ParseDuplicatePolicy(duplicates)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	cityOrder, err := invasion.ParseCityOrder(order)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	parseOpts := []invasion.ParseOption{
+		invasion.WithDuplicatePolicy(dupPolicy),
+		invasion.WithMaxLineLength(maxLineLen),
+	}
+	if progress {
+		parseOpts = append(parseOpts, invasion.WithProgress(progressReporter(mapFile)))
+	}
+
+	worldMap, err := invasion.ReadWorldMapFile(mapFile, mapFormat, parseOpts...)
+	if progress {
+		fmt.Fprintln(os.Stderr)
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	invasion.Start(out, worldMap, numOfAliens)
+	worldMap.SetCityOrder(cityOrder)
+	report := invasion.Start(out, worldMap, numOfAliens)
 
 	if buf, ok := out.(*bytes.Buffer); ok {
 		if err := os.WriteFile(outputFile, buf.Bytes(), os.ModePerm); err != nil {
@@ -51,18 +89,36 @@ func main() {
 		}
 		fmt.Printf("File %q was created successfully.\n", outputFile)
 	}
+
+	if dotFile != "" {
+		writeGraphFile(dotFile, invasion.EncodeDOT, worldMap, report)
+	}
+	if graphmlFile != "" {
+		writeGraphFile(graphmlFile, invasion.EncodeGraphML, worldMap, report)
+	}
 }
 
-func parseWorldMapFile(fname string) (*invasion.WorldMap, error) {
-	f, err := os.Open(fname)
-	if err != nil {
-		return nil, err
+func writeGraphFile(fname string, encode func(io.Writer, *invasion.WorldMap, ...invasion.EncodeOption) error, worldMap *invasion.WorldMap, report *invasion.Report) {
+	buf := &bytes.Buffer{}
+	if err := encode(buf, worldMap, invasion.WithReport(report)); err != nil {
+		log.Fatalln(err)
 	}
-	defer f.Close()
-	s := bufio.NewScanner(f)
-	worldMap, err := invasion.ParseWorldMap(s)
-	if err != nil {
-		return nil, err
+	if err := os.WriteFile(fname, buf.Bytes(), os.ModePerm); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("File %q was created successfully.\n", fname)
+}
+
+func progressReporter(fname string) func(int64) {
+	var size int64
+	if fi, err := os.Stat(fname); err == nil {
+		size = fi.Size()
+	}
+	return func(bytesRead int64) {
+		if size > 0 {
+			fmt.Fprintf(os.Stderr, "\rReading the world map: %d%%", bytesRead*100/size)
+		} else {
+			fmt.Fprintf(os.Stderr, "\rReading the world map: %d bytes", bytesRead)
+		}
 	}
-	return worldMap, nil
 }
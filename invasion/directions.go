@@ -2,62 +2,84 @@ package invasion
 
 import "fmt"
 
-// direction defines direction type (N, S, E, O)
-type direction int
+// Direction defines direction type (N, S, E, O)
+type Direction int
 
-// direction options
+// Direction options
 const (
-	dirNorth direction = iota
-	dirSouth
-	dirEast
-	dirWest
+	North Direction = iota
+	South
+	East
+	West
 )
 
-// directionFromString converts a valid string direction
-// into a direction type. If the string is not a valid direction
+// ParseDirection converts a valid string direction
+// into a Direction type. If the string is not a valid direction
 // this will return an error.
-func directionFromString(s string) (direction, error) {
+func ParseDirection(s string) (Direction, error) {
 	switch s {
 	case "north":
-		return dirNorth, nil
+		return North, nil
 	case "south":
-		return dirSouth, nil
+		return South, nil
 	case "east":
-		return dirEast, nil
+		return East, nil
 	case "west":
-		return dirWest, nil
+		return West, nil
 	}
 	return -1, fmt.Errorf("%s is not a valid direction", s)
 }
 
-// opposite returns the opposite direction of this direction.
-// For example calling opposite() with dirNorth should return
-// dirSouth.
-func (d direction) opposite() direction {
+// Opposite returns the opposite direction of this direction.
+// For example calling Opposite() with North should return
+// South.
+func (d Direction) Opposite() Direction {
 	switch d {
-	case dirNorth:
-		return dirSouth
-	case dirSouth:
-		return dirNorth
-	case dirEast:
-		return dirWest
-	case dirWest:
-		return dirEast
+	case North:
+		return South
+	case South:
+		return North
+	case East:
+		return West
+	case West:
+		return East
 	}
 	return -1
 }
 
+// valid reports whether the direction is one of the four directions.
+//
+func (d Direction) valid() bool {
+	return d >= North && d <= West
+}
+
+// offset returns the coordinates offset of moving one city towards this
+// direction. X grows to the east and Y grows to the south.
+func (d Direction) offset() (dx, dy int) {
+	switch d {
+	case North:
+		return 0, -1
+	case South:
+		return 0, 1
+	case East:
+		return 1, 0
+	case West:
+		return -1, 0
+	}
+	return 0, 0
+}
+
 // String implements fmt.Stringer. Will return the equivalent direction
 // string in lowercase letters.
-func (d direction) String() string {
+func (d Direction) String() string {
 	switch d {
-	case dirNorth:
+	case North:
 		return "north"
-	case dirSouth:
+	case South:
 		return "south"
-	case dirEast:
+	case East:
 		return "east"
-	case dirWest:
+	case West:
 		return "west"
 	}
 	return "invalid direction"
@@ -8,51 +8,64 @@ import (
 
 func TestDirections_fromString(t *testing.T) {
 	t.Run("invalid direction", func(t *testing.T) {
-		_, err := directionFromString("invalid")
+		_, err := ParseDirection("invalid")
 		assert.Error(t, err)
 		assert.Equal(t, "invalid is not a valid direction", err.Error())
 
-		_, err = directionFromString("North")
+		_, err = ParseDirection("North")
 		assert.Error(t, err)
 		assert.Equal(t, "North is not a valid direction", err.Error())
 	})
 
 	t.Run("valid direction", func(t *testing.T) {
-		d, err := directionFromString("north")
+		d, err := ParseDirection("north")
 		assert.NoError(t, err)
-		assert.Equal(t, dirNorth, d)
+		assert.Equal(t, North, d)
 
-		d, err = directionFromString("south")
+		d, err = ParseDirection("south")
 		assert.NoError(t, err)
-		assert.Equal(t, dirSouth, d)
+		assert.Equal(t, South, d)
 
-		d, err = directionFromString("east")
+		d, err = ParseDirection("east")
 		assert.NoError(t, err)
-		assert.Equal(t, dirEast, d)
+		assert.Equal(t, East, d)
 
-		d, err = directionFromString("west")
+		d, err = ParseDirection("west")
 		assert.NoError(t, err)
-		assert.Equal(t, dirWest, d)
+		assert.Equal(t, West, d)
 	})
 
 }
 
 func TestDirections_opposite(t *testing.T) {
 	t.Run("invalid direction", func(t *testing.T) {
-		assert.Equal(t, direction(-1), direction(5).opposite())
+		assert.Equal(t, Direction(-1), Direction(5).Opposite())
 	})
 	t.Run("valid direction", func(t *testing.T) {
-		assert.Equal(t, dirSouth, dirNorth.opposite())
-		assert.Equal(t, dirNorth, dirSouth.opposite())
-		assert.Equal(t, dirEast, dirWest.opposite())
-		assert.Equal(t, dirWest, dirEast.opposite())
+		assert.Equal(t, South, North.Opposite())
+		assert.Equal(t, North, South.Opposite())
+		assert.Equal(t, East, West.Opposite())
+		assert.Equal(t, West, East.Opposite())
 	})
 }
 
+func TestDirections_offset(t *testing.T) {
+	dx, dy := Direction(5).offset()
+	assert.Equal(t, [2]int{0, 0}, [2]int{dx, dy})
+	dx, dy = North.offset()
+	assert.Equal(t, [2]int{0, -1}, [2]int{dx, dy})
+	dx, dy = South.offset()
+	assert.Equal(t, [2]int{0, 1}, [2]int{dx, dy})
+	dx, dy = East.offset()
+	assert.Equal(t, [2]int{1, 0}, [2]int{dx, dy})
+	dx, dy = West.offset()
+	assert.Equal(t, [2]int{-1, 0}, [2]int{dx, dy})
+}
+
 func TestDirections_String(t *testing.T) {
-	assert.Equal(t, "invalid direction", direction(8).String())
-	assert.Equal(t, "north", dirNorth.String())
-	assert.Equal(t, "south", dirSouth.String())
-	assert.Equal(t, "east", dirEast.String())
-	assert.Equal(t, "west", dirWest.String())
+	assert.Equal(t, "invalid direction", Direction(8).String())
+	assert.Equal(t, "north", North.String())
+	assert.Equal(t, "south", South.String())
+	assert.Equal(t, "east", East.String())
+	assert.Equal(t, "west", West.String())
 }
@@ -9,16 +9,46 @@ import (
 	"strings"
 )
 
+// resultMapHeader is the line written by Start before the result map.
+const resultMapHeader = "Result map:"
+
 // tStarterCityForAlienFn is only used for test purposes and it should not be
 // changed in files different from *_test.go
 var tStarterCityNameForAlienFn func(alien int) string
 
-// Start starts the invasion.
+// Report summarizes the result of an invasion.
+type Report struct {
+	// Destroyed has the destroyed cities, in destruction order.
+	Destroyed []DestroyedCity
+	// Aliens has the aliens that survived the invasion, sorted by number.
+	Aliens []AlienStatus
+}
+
+// DestroyedCity represents a city destroyed during the invasion.
+type DestroyedCity struct {
+	Name string
+	// Iteration is the invasion iteration (starting at 1) when the city
+	// was destroyed.
+	Iteration int
+	// Aliens has the numbers of the aliens that destroyed the city, sorted.
+	Aliens []int
+	// links has the names of the surrounding cities when the city was destroyed.
+	links [4]string
+}
+
+// AlienStatus represents an alien that survived the invasion.
+type AlienStatus struct {
+	Num     int
+	City    string
+	Trapped bool
+}
+
+// Start starts the invasion and returns a report with its result.
 //
-func Start(out io.Writer, wmap *WorldMap, numOfAliens int) {
+func Start(out io.Writer, wmap *WorldMap, numOfAliens int) *Report {
 
 	if wmap == nil || numOfAliens == 0 {
-		return
+		return nil
 	}
 
 	if out == nil {
@@ -49,6 +79,7 @@ func Start(out io.Writer, wmap *WorldMap, numOfAliens int) {
 	}
 
 	// start alien invasion
+	report := &Report{}
 	nonTrappedAlienMoves := 0
 	for {
 
@@ -89,9 +120,18 @@ func Start(out io.Writer, wmap *WorldMap, numOfAliens int) {
 
 		nonTrappedAlienMoves++
 
+		destroyedFrom := len(report.Destroyed)
 		for c, aSet := range cityAliensMap {
 			if aSet.len() >= 2 {
-				wmap.destroyCity(c)
+				dc := DestroyedCity{Name: c, Iteration: nonTrappedAlienMoves, Aliens: aSet.sorted()}
+				if destroyed := wmap.destroyCity(c); destroyed != nil {
+					for i, sc := range destroyed.dirs {
+						if sc != nil {
+							dc.links[i] = sc.name
+						}
+					}
+				}
+				report.Destroyed = append(report.Destroyed, dc)
 				fmt.Fprintf(out, "%s has been destroyed by %s!\n", c, aSet)
 				for a := range aSet.data {
 					delete(aliensMap, a)
@@ -99,11 +139,25 @@ func Start(out io.Writer, wmap *WorldMap, numOfAliens int) {
 				delete(cityAliensMap, c)
 			}
 		}
+		// cities destroyed in the same iteration are sorted by name
+		sameIteration := report.Destroyed[destroyedFrom:]
+		sort.Slice(sameIteration, func(i, j int) bool {
+			return sameIteration[i].Name < sameIteration[j].Name
+		})
 
 	}
 
-	fmt.Fprintln(out, "\nResult map:")
+	for _, a := range aliensMap {
+		report.Aliens = append(report.Aliens, AlienStatus{Num: a.num, City: a.curCity.name, Trapped: a.trapped})
+	}
+	sort.Slice(report.Aliens, func(i, j int) bool {
+		return report.Aliens[i].Num < report.Aliens[j].Num
+	})
+
+	fmt.Fprintf(out, "\n%s\n", resultMapHeader)
 	wmap.print(out)
+
+	return report
 }
 
 // ===============================================================
@@ -178,6 +232,18 @@ func (s *alienSet) remove(n int) {
 	delete(s.data, n)
 }
 
+// sorted returns the aliens of the set sorted ascending.
+func (s *alienSet) sorted() []int {
+	aliens := make([]int, 0, len(s.data))
+	for anum := range s.data {
+		aliens = append(aliens, anum)
+	}
+	sort.Slice(aliens, func(i, j int) bool {
+		return aliens[i] < aliens[j]
+	})
+	return aliens
+}
+
 // String implements fmt.Stringer. This will return
 // a string with all the aliens in the form:
 // 		`alien x, alien y and alien z`
@@ -186,13 +252,7 @@ func (s *alienSet) String() string {
 	if s.len() == 0 {
 		return ""
 	}
-	aliens := make([]int, 0, len(s.data))
-	for anum := range s.data {
-		aliens = append(aliens, anum)
-	}
-	sort.Slice(aliens, func(i, j int) bool {
-		return aliens[i] < aliens[j]
-	})
+	aliens := s.sorted()
 	sb := &strings.Builder{}
 	sb.Grow(9*s.len() + 3)
 	for i, a := range aliens {
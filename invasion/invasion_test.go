@@ -274,15 +274,47 @@ func TestInvasion_Start(t *testing.T) {
 		assert.Contains(t, ret, "No cities in the map.")
 	})
 
+	t.Run("report", func(t *testing.T) {
+		wm := parseSmallMap(t)
+
+		moves := map[int]*moveList{
+			0: newMoveList(wm, "C1", "C2"),
+			1: newMoveList(wm, "C3", "C2"),
+			2: newMoveList(wm, "C7", "C8", "C5"),
+			3: newMoveList(wm, "C9", "C8", "C5"),
+			4: newMoveList(wm, "C6", "C9"),
+		}
+
+		tStarterCityNameForAlienFn = func(alien int) string {
+			return moves[alien].poll().name
+		}
+		tAlienMoveNextCityFn = func(alien int, curCity *city, possibleCities []*city) *city {
+			ret := moves[alien].poll()
+			require.Contains(t, possibleCities, ret, fmt.Sprintf("alien %d can't go to %s from %s", alien, ret.name, curCity.name))
+			return ret
+		}
+
+		r := Start(&bytes.Buffer{}, wm, len(moves))
+		assert.Equal(t, &Report{
+			Destroyed: []DestroyedCity{
+				{Name: "C2", Iteration: 1, Aliens: []int{0, 1}, links: [4]string{South: "C5", East: "C3", West: "C1"}},
+				{Name: "C8", Iteration: 1, Aliens: []int{2, 3}, links: [4]string{North: "C5", East: "C9", West: "C7"}},
+			},
+			Aliens: []AlienStatus{
+				{Num: 4, City: "C6", Trapped: false},
+			},
+		}, r)
+	})
+
 	t.Run("passing nil map or 0 num of aliens", func(t *testing.T) {
 		// don't do anything if nil map or 0 numOfAliens are passed
 		buf := &bytes.Buffer{}
-		Start(buf, nil, 2)
+		assert.Nil(t, Start(buf, nil, 2))
 		assert.Empty(t, buf.Bytes())
 
 		buf.Reset()
 		wm := parseSmallMap(t)
-		Start(buf, wm, 0)
+		assert.Nil(t, Start(buf, wm, 0))
 		assert.Empty(t, buf.Bytes())
 	})
 }
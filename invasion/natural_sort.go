@@ -0,0 +1,58 @@
+package invasion
+
+// naturalLess reports whether a goes before b in natural order, where the
+// digit sequences are compared by their numeric value, so C2 goes before
+// C10. Names that only differ in leading zeros (C01 and C1) are compared
+// lexicographically, so the order is always total.
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return a[i] < b[j]
+			}
+			i++
+			j++
+			continue
+		}
+
+		// compare the digit sequences ignoring their leading zeros
+		si, sj := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		na, nb := trimLeadingZeros(a[si:i]), trimLeadingZeros(b[sj:j])
+		if len(na) != len(nb) {
+			return len(na) < len(nb)
+		}
+		if na != nb {
+			return na < nb
+		}
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// isDigit reports whether the byte is an ASCII digit.
+//
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// trimLeadingZeros removes the leading zeros of a digit sequence.
+//
+func trimLeadingZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,20 @@
+package invasion
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"C10", "C2", "B", "C1", "C02", "C", "C1a", "C1b10", "C1b9", "Town", "A100x", "A99y", "C0"}
+	sort.Slice(names, func(i, j int) bool {
+		return naturalLess(names[i], names[j])
+	})
+	assert.Equal(t, []string{"A99y", "A100x", "B", "C", "C0", "C1", "C1a", "C1b9", "C1b10", "C02", "C2", "C10", "Town"}, names)
+
+	assert.False(t, naturalLess("C1", "C1"))
+	assert.True(t, naturalLess("C01", "C1"))
+	assert.False(t, naturalLess("C1", "C01"))
+}
@@ -0,0 +1,151 @@
+package invasion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// attrPrefix is the prefix that identifies a city attribute in a map line,
+// e.g. `@population=12000`.
+const attrPrefix = "@"
+
+// CityAttributes returns a copy of the attributes of the specified city.
+// If the city does not exist or has no attributes, this returns nil.
+func (m *WorldMap) CityAttributes(name string) map[string]string {
+	c, ok := m.cities[name]
+	if !ok || len(c.attrs) == 0 {
+		return nil
+	}
+	attrs := make(map[string]string, len(c.attrs))
+	for k, v := range c.attrs {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// CityAttribute returns the value of an attribute of the specified city
+// and whether it was found.
+func (m *WorldMap) CityAttribute(name, key string) (string, bool) {
+	c, ok := m.cities[name]
+	if !ok {
+		return "", false
+	}
+	v, ok := c.attrs[key]
+	return v, ok
+}
+
+// SetCityAttribute sets an attribute of the specified city. An empty value
+// removes the attribute. Keys and values must be valid in the map file format.
+func (m *WorldMap) SetCityAttribute(name, key, value string) error {
+	c, ok := m.cities[name]
+	if !ok {
+		return fmt.Errorf("city %s does not exist", name)
+	}
+	if err := validateAttrKey(key); err != nil {
+		return err
+	}
+	if value == "" {
+		delete(c.attrs, key)
+		return nil
+	}
+	if err := validateAttrValue(key, value); err != nil {
+		return err
+	}
+	if c.attrs == nil {
+		c.attrs = make(map[string]string)
+	}
+	c.attrs[key] = value
+	return nil
+}
+
+// Metadata returns a copy of the map metadata. If the map has no metadata,
+// this returns nil.
+func (m *WorldMap) Metadata() map[string]string {
+	if len(m.meta) == 0 {
+		return nil
+	}
+	meta := make(map[string]string, len(m.meta))
+	for k, v := range m.meta {
+		meta[k] = v
+	}
+	return meta
+}
+
+// SetMetadata sets a metadata entry of the map. An empty value removes the
+// entry. Keys and values follow the same rules as the city attributes.
+func (m *WorldMap) SetMetadata(key, value string) error {
+	if err := validateAttrKey(key); err != nil {
+		return err
+	}
+	if value == "" {
+		delete(m.meta, key)
+		return nil
+	}
+	if err := validateAttrValue(key, value); err != nil {
+		return err
+	}
+	if m.meta == nil {
+		m.meta = make(map[string]string)
+	}
+	m.meta[key] = value
+	return nil
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// validateAttrKey checks that an attribute key (without the '@' prefix)
+// only has letters, numbers or any of the `_ . : -` characters.
+func validateAttrKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("empty attribute name")
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_', r == '.', r == ':', r == '-':
+		default:
+			return fmt.Errorf("%s%s is not a valid attribute name", attrPrefix, key)
+		}
+	}
+	return nil
+}
+
+// validateAttrValue checks that an attribute value can be written in a
+// map line.
+func validateAttrValue(key, value string) error {
+	if value == "" {
+		return fmt.Errorf("attribute %s%s without value", attrPrefix, key)
+	}
+	if strings.ContainsAny(value, "=# \t\r\n") {
+		return fmt.Errorf("attribute %s%s has an invalid value %q", attrPrefix, key, value)
+	}
+	return nil
+}
+
+// decodeMetadataComment decodes a header comment in the form `@key=value`.
+// If the comment is not a valid metadata entry, ok is false.
+func decodeMetadataComment(comment string) (key, value string, ok bool) {
+	c := strings.TrimSpace(comment)
+	if !strings.HasPrefix(c, attrPrefix) {
+		return "", "", false
+	}
+	m := strings.Split(strings.TrimPrefix(c, attrPrefix), "=")
+	if len(m) != 2 || validateAttrKey(m[0]) != nil || validateAttrValue(m[0], m[1]) != nil {
+		return "", "", false
+	}
+	return m[0], m[1], true
+}
+
+// sortedAttrKeys returns the attribute keys sorted.
+//
+func sortedAttrKeys(attrs map[string]string) []string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package invasion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_CityAttributes(t *testing.T) {
+
+	wm, err := parseMapString(`C5 north=C2 @population=12000 @defense=3 @terrain=mountain
+C2 @game:owner=red
+`)
+	require.NoError(t, err)
+
+	t.Run("read attributes", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"population": "12000", "defense": "3", "terrain": "mountain",
+		}, wm.CityAttributes("C5"))
+		assert.Equal(t, map[string]string{"game:owner": "red"}, wm.CityAttributes("C2"))
+		assert.Nil(t, wm.CityAttributes("not-exist"))
+
+		v, ok := wm.CityAttribute("C5", "defense")
+		assert.True(t, ok)
+		assert.Equal(t, "3", v)
+		_, ok = wm.CityAttribute("C5", "unknown")
+		assert.False(t, ok)
+		_, ok = wm.CityAttribute("not-exist", "defense")
+		assert.False(t, ok)
+	})
+
+	t.Run("returned attributes are a copy", func(t *testing.T) {
+		attrs := wm.CityAttributes("C5")
+		attrs["defense"] = "100"
+		v, _ := wm.CityAttribute("C5", "defense")
+		assert.Equal(t, "3", v)
+	})
+
+	t.Run("set attributes", func(t *testing.T) {
+		assert.NoError(t, wm.SetCityAttribute("C2", "population", "500"))
+		assert.NoError(t, wm.SetCityAttribute("C2", "game:owner", ""))
+		assert.Equal(t, map[string]string{"population": "500"}, wm.CityAttributes("C2"))
+
+		assert.EqualError(t, wm.SetCityAttribute("not-exist", "population", "1"), "city not-exist does not exist")
+		assert.EqualError(t, wm.SetCityAttribute("C2", "pop ulation", "1"), "@pop ulation is not a valid attribute name")
+		assert.EqualError(t, wm.SetCityAttribute("C2", "population", "1 000"), `attribute @population has an invalid value "1 000"`)
+	})
+
+	t.Run("attributes are written back", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, wm))
+		assert.Equal(t, `C2 south=C5 @population=500
+C5 north=C2 @defense=3 @population=12000 @terrain=mountain
+`, buf.String())
+	})
+}
+
+func TestWorldMap_DuplicatedCityAttributes(t *testing.T) {
+
+	data := "C1 @defense=3\nC1 east=C2 @defense=4 @population=10\n"
+
+	t.Run("merge", func(t *testing.T) {
+		wm, err := parseMapString(data)
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: conflicting definitions of city C1 (@defense=3 and @defense=4)")
+
+		wm, err = parseMapString("C1 @defense=3\nC1 east=C2 @population=10\n")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"defense": "3", "population": "10"}, wm.CityAttributes("C1"))
+	})
+
+	t.Run("last wins", func(t *testing.T) {
+		var warnings []string
+		wm, err := parseMapString(data,
+			WithDuplicatePolicy(DuplicateLastWins),
+			WithWarningHandler(func(msg string) { warnings = append(warnings, msg) }),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"city C1 is defined more than once, @defense=4 overrides @defense=3"}, warnings)
+		assert.Equal(t, map[string]string{"defense": "4", "population": "10"}, wm.CityAttributes("C1"))
+	})
+}
+
+func TestWorldMap_Metadata(t *testing.T) {
+
+	wm, err := parseMapString(`# @author=qa
+#@version=2
+# @not metadata
+C1 east=C2
+# @ignored=after-the-first-city
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"author": "qa", "version": "2"}, wm.Metadata())
+	assert.Equal(t, []string{" @not metadata"}, wm.header)
+
+	assert.NoError(t, wm.SetMetadata("version", ""))
+	assert.NoError(t, wm.SetMetadata("generator", "mapgen"))
+	assert.EqualError(t, wm.SetMetadata("generator", "map gen"), `attribute @generator has an invalid value "map gen"`)
+	assert.EqualError(t, wm.SetMetadata("", "x"), "empty attribute name")
+	assert.Equal(t, map[string]string{"author": "qa", "generator": "mapgen"}, wm.Metadata())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteWorldMap(buf, wm))
+	assert.Equal(t, `# @author=qa
+# @generator=mapgen
+# @not metadata
+C1 east=C2
+C2 west=C1
+`, buf.String())
+
+	assert.Nil(t, (&WorldMap{}).Metadata())
+}
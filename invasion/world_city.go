@@ -1,14 +1,16 @@
 package invasion
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
 // city defines a city in the map with its surroundings.
 type city struct {
-	name string
-	dirs [4]*city
+	name  string
+	dirs  [4]*city
+	attrs map[string]string
 }
 
 // surroundingCities returns the surrounding cities using the dirs field.
@@ -43,11 +45,37 @@ func (c *city) String() string {
 			continue
 		}
 		sb.WriteByte(' ')
-		sb.WriteString(fmt.Sprintf("%s=%s", direction(i), surCity.name))
+		sb.WriteString(fmt.Sprintf("%s=%s", Direction(i), surCity.name))
+	}
+	for _, k := range sortedAttrKeys(c.attrs) {
+		sb.WriteByte(' ')
+		sb.WriteString(fmt.Sprintf("%s%s=%s", attrPrefix, k, c.attrs[k]))
 	}
 	return sb.String()
 }
 
+// validateCityName checks that a city name can be written in a map line.
+//
+func validateCityName(name string) error {
+	if name == "" {
+		return errors.New("empty city name")
+	}
+	if strings.HasPrefix(name, attrPrefix) {
+		return fmt.Errorf("%q is not a valid city name", name)
+	}
+	// a table lookup per byte is much faster than strings.ContainsAny for
+	// short names, and the names of huge maps are validated one by one
+	for i := 0; i < len(name); i++ {
+		if invalidNameBytes[name[i]] {
+			return fmt.Errorf("%q is not a valid city name", name)
+		}
+	}
+	return nil
+}
+
+// invalidNameBytes has the bytes that cannot be part of a city name.
+var invalidNameBytes = [256]bool{'=': true, '#': true, ' ': true, '\t': true, '\r': true, '\n': true}
+
 // reachedCitiesFrom returns all the possible cities that can be reached from the `fromCity`
 // including `fromCity`.
 func reachedCitiesFrom(reachedCities map[string]struct{}, fromCity *city) map[string]struct{} {
@@ -11,10 +11,10 @@ func TestCity_surroundingCities(t *testing.T) {
 	c := &city{
 		name: "C1",
 		dirs: [4]*city{
-			dirNorth: {name: "C2"},
-			dirSouth: {name: "C3"},
-			dirEast:  {name: "C4"},
-			dirWest:  {name: "C5"},
+			North: {name: "C2"},
+			South: {name: "C3"},
+			East:  {name: "C4"},
+			West:  {name: "C5"},
 		},
 	}
 
@@ -25,8 +25,8 @@ func TestCity_surroundingCities(t *testing.T) {
 
 	// -- case after removing some directions
 
-	c.dirs[dirEast] = nil
-	c.dirs[dirNorth] = nil
+	c.dirs[East] = nil
+	c.dirs[North] = nil
 
 	surCities = c.surroundingCities()
 
@@ -40,10 +40,10 @@ func TestCity_String(t *testing.T) {
 	c := &city{
 		name: "C1",
 		dirs: [4]*city{
-			dirNorth: {name: "C2"},
-			dirSouth: {name: "C3"},
-			dirEast:  {name: "C4"},
-			dirWest:  {name: "C5"},
+			North: {name: "C2"},
+			South: {name: "C3"},
+			East:  {name: "C4"},
+			West:  {name: "C5"},
 		},
 	}
 
@@ -51,10 +51,16 @@ func TestCity_String(t *testing.T) {
 
 	// -- case after removing some directions
 
-	c.dirs[dirEast] = nil
-	c.dirs[dirNorth] = nil
+	c.dirs[East] = nil
+	c.dirs[North] = nil
 
 	assert.Equal(t, "C1 south=C3 west=C5", c.String())
+
+	// -- attributes are written sorted after the directions
+
+	c.attrs = map[string]string{"terrain": "mountain", "defense": "3"}
+
+	assert.Equal(t, "C1 south=C3 west=C5 @defense=3 @terrain=mountain", c.String())
 }
 
 func TestCity_reachedCities(t *testing.T) {
@@ -0,0 +1,164 @@
+package invasion
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Point represents integer coordinates on the plane. X grows to the east
+// and Y grows to the south, like the grids created by mapgen.
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// String implements fmt.Stringer.
+func (p Point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+// Layout holds the coordinates inferred for the cities of a world map.
+type Layout struct {
+	// Coords has the coordinates of the cities of every embedded component.
+	Coords map[string]Point
+	// Components has all the connected components of the map.
+	Components []LayoutComponent
+}
+
+// LayoutComponent represents a connected component of the world map.
+type LayoutComponent struct {
+	// Cities has the names of the component cities, sorted.
+	Cities []string
+	// Conflict explains why the component cannot be embedded in the plane.
+	// It is empty if the component was embedded. Any coordinates in the
+	// explanation are relative to the first city of the component.
+	Conflict string
+}
+
+// Embedded reports whether the component could be embedded in the plane.
+func (c LayoutComponent) Embedded() bool {
+	return c.Conflict == ""
+}
+
+// Embedded reports whether all the components could be embedded in the plane.
+func (l *Layout) Embedded() bool {
+	for _, c := range l.Components {
+		if !c.Embedded() {
+			return false
+		}
+	}
+	return true
+}
+
+// Layout assigns integer coordinates to every city by propagating the
+// north/south/east/west offsets through each connected component.
+//
+// Each embedded component has its top-left corner at Y=0 and is placed at
+// the right of the previous one, leaving an empty column between them.
+// Components whose links lead a city to two different positions, or two
+// cities to the same position, are reported as not embedded and their cities
+// get no coordinates.
+func (m *WorldMap) Layout() *Layout {
+	l := &Layout{Coords: make(map[string]Point, len(m.cities))}
+
+	names := make([]string, 0, len(m.cities))
+	for name := range m.cities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	visited := make(map[string]struct{}, len(m.cities))
+	nextX := 0
+	for _, name := range names {
+		if _, ok := visited[name]; ok {
+			continue
+		}
+		coords, conflict := embedComponent(m.cities[name])
+		comp := LayoutComponent{Cities: make([]string, 0, len(coords)), Conflict: conflict}
+		for cn := range coords {
+			visited[cn] = struct{}{}
+			comp.Cities = append(comp.Cities, cn)
+		}
+		sort.Strings(comp.Cities)
+		l.Components = append(l.Components, comp)
+		if !comp.Embedded() {
+			continue
+		}
+
+		minX, minY, maxX := 0, 0, 0
+		for _, p := range coords {
+			minX, minY, maxX = minInt(minX, p.X), minInt(minY, p.Y), maxInt(maxX, p.X)
+		}
+		for cn, p := range coords {
+			l.Coords[cn] = Point{X: p.X - minX + nextX, Y: p.Y - minY}
+		}
+		nextX += maxX - minX + 2
+	}
+
+	return l
+}
+
+// embedComponent assigns coordinates to all the cities that can be reached
+// from the root city, placing the root at (0, 0). If the component cannot be
+// embedded, the returned conflict explains why.
+func embedComponent(root *city) (map[string]Point, string) {
+	coords := map[string]Point{root.name: {}}
+	conflict := ""
+	queue := []*city{root}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		p := coords[c.name]
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			dx, dy := Direction(i).offset()
+			want := Point{X: p.X + dx, Y: p.Y + dy}
+			got, ok := coords[sc.name]
+			if !ok {
+				coords[sc.name] = want
+				queue = append(queue, sc)
+				continue
+			}
+			if got != want && conflict == "" {
+				conflict = fmt.Sprintf("city %s is placed at %s but %s of %s is %s", sc.name, got, Direction(i), c.name, want)
+			}
+		}
+	}
+	if conflict != "" {
+		return coords, conflict
+	}
+
+	// two cities cannot share the same position
+	names := make([]string, 0, len(coords))
+	for name := range coords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	taken := make(map[Point]string, len(coords))
+	for _, name := range names {
+		p := coords[name]
+		if other, ok := taken[p]; ok {
+			return coords, fmt.Sprintf("cities %s and %s overlap at %s", other, name, p)
+		}
+		taken[p] = name
+	}
+	return coords, ""
+}
+
+// minInt returns the minimum of two integers.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the maximum of two integers.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
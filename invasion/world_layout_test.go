@@ -0,0 +1,72 @@
+package invasion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_Layout(t *testing.T) {
+
+	t.Run("grid map", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		l := wm.Layout()
+		assert.True(t, l.Embedded())
+		assert.Equal(t, map[string]Point{
+			"C1": {0, 0}, "C2": {1, 0}, "C3": {2, 0},
+			"C4": {0, 1}, "C5": {1, 1}, "C6": {2, 1},
+			"C7": {0, 2}, "C8": {1, 2}, "C9": {2, 2},
+		}, l.Coords)
+		assert.Equal(t, []LayoutComponent{
+			{Cities: []string{"C1", "C2", "C3", "C4", "C5", "C6", "C7", "C8", "C9"}},
+		}, l.Components)
+	})
+
+	t.Run("components are placed side by side", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		wm.destroyCity("C2")
+		wm.destroyCity("C5")
+		wm.destroyCity("C8")
+		l := wm.Layout()
+		assert.True(t, l.Embedded())
+		assert.Equal(t, map[string]Point{
+			"C1": {0, 0}, "C4": {0, 1}, "C7": {0, 2},
+			"C3": {2, 0}, "C6": {2, 1}, "C9": {2, 2},
+		}, l.Coords)
+		assert.Equal(t, []LayoutComponent{
+			{Cities: []string{"C1", "C4", "C7"}},
+			{Cities: []string{"C3", "C6", "C9"}},
+		}, l.Components)
+	})
+
+	t.Run("component with inconsistent offsets", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB south=C\nC west=D\nD south=A\nE east=F\n")
+		require.NoError(t, err)
+		l := wm.Layout()
+		assert.False(t, l.Embedded())
+		assert.Equal(t, []LayoutComponent{
+			{Cities: []string{"A", "B", "C", "D"}, Conflict: "city C is placed at (1, -1) but south of B is (1, 1)"},
+			{Cities: []string{"E", "F"}},
+		}, l.Components)
+		assert.Equal(t, map[string]Point{"E": {0, 0}, "F": {1, 0}}, l.Coords)
+	})
+
+	t.Run("component with overlapping cities", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB south=C\nC west=D\nD north=E\n")
+		require.NoError(t, err)
+		l := wm.Layout()
+		assert.False(t, l.Embedded())
+		assert.Equal(t, []LayoutComponent{
+			{Cities: []string{"A", "B", "C", "D", "E"}, Conflict: "cities A and E overlap at (0, 0)"},
+		}, l.Components)
+		assert.Empty(t, l.Coords)
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		l := (&WorldMap{cities: map[string]*city{}}).Layout()
+		assert.True(t, l.Embedded())
+		assert.Empty(t, l.Coords)
+		assert.Empty(t, l.Components)
+	})
+}
@@ -12,47 +12,79 @@ import (
 // WorldMap represents the simulated world map.
 type WorldMap struct {
 	cities map[string]*city
+	// header has the comment lines found before the first city line,
+	// without the leading '#'.
+	header []string
+	// meta has the map metadata, written as `# @key=value` header lines.
+	meta map[string]string
+	// fileOrder has the city names in the order they were read. It can
+	// have the names of destroyed cities.
+	fileOrder []string
+	// order is the order used by WriteTo.
+	order CityOrder
 }
 
+// noCitiesLine is the line written by WriteTo instead of the cities of an
+// empty map.
+const noCitiesLine = "No cities in the map."
+
+// errInconsistentMap is returned when the links between two cities
+// do not match (e.g. A north=B but B south=C).
+var errInconsistentMap = errors.New("Cannot parse the map: inconsistent map")
+
 // ParseWorldMap parses the simulated world map.
 //
-func ParseWorldMap(s *bufio.Scanner) (*WorldMap, error) {
+// Lines may end with a '#' comment and comment-only lines are skipped. The
+// comment lines found before the first city are kept as the map header, and
+// they are written back by WriteWorldMap. Header comments in the form
+// `# @key=value` are the map metadata.
+//
+// By default a city defined in more than one line gets all its definitions
+// merged, see WithDuplicatePolicy for other options.
+//
+// The scanner limits the line length (64KB by default), use ReadWorldMap to
+// parse maps with longer lines.
+func ParseWorldMap(s *bufio.Scanner, opts ...ParseOption) (*WorldMap, error) {
+	b := newMapBuilder(opts)
+	if err := parseMapLines(s, b); err != nil {
+		return nil, err
+	}
+	return b.build(), nil
+}
 
-	wmap := &WorldMap{cities: make(map[string]*city)}
+// parseMapLines parses all the lines of the scanner, adding the cities and
+// header comments to the map builder.
+func parseMapLines(s *bufio.Scanner, b *mapBuilder) error {
 
 	for s.Scan() {
 		// read one line
 		line := s.Text()
-		if line == "" {
+		content, comment, hasComment := splitMapLineComment(line)
+		if strings.TrimSpace(content) == "" {
+			if hasComment {
+				b.addComment(comment)
+			}
 			continue
 		}
 
-		data, err := decodeMapLine(line)
+		data, err := decodeMapLine(content)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		curCity := wmap.getOrCreateCity(data.name)
-		for i, d := range data.dirs {
-			curCity.dirs[i] = wmap.getOrCreateCity(d)
-		}
-		for i, surCity := range curCity.dirs {
-			if surCity == nil {
-				continue
-			}
-			oppositeDir := direction(i).opposite()
-			if surCity.dirs[oppositeDir] == nil {
-				surCity.dirs[oppositeDir] = curCity
-			} else if surCity.dirs[oppositeDir].name != curCity.name {
-				return nil, errors.New("Cannot parse the map: inconsistent map")
-			}
+		if err := b.addCity(data); err != nil {
+			return err
 		}
 	}
 
 	if err := s.Err(); err != nil {
-		return nil, err
+		var lineErr *LineTooLongError
+		if errors.As(err, &lineErr) {
+			return err
+		}
+		return fmt.Errorf("Cannot parse the map: %w", err)
 	}
 
-	return wmap, nil
+	return nil
 }
 
 // getOrCreateCity gets or creates a city with the specified name in the map.
@@ -69,29 +101,81 @@ func (m *WorldMap) getOrCreateCity(name string) *city {
 	return c
 }
 
-// destroyCity destroys a city in the world map.
-//
-func (m *WorldMap) destroyCity(name string) {
+// destroyCity destroys a city in the world map and returns it. If the
+// city does not exist, this returns nil.
+func (m *WorldMap) destroyCity(name string) *city {
 	c, ok := m.cities[name]
 	if !ok {
-		return
+		return nil
 	}
 	for i, sc := range c.dirs {
 		if sc == nil {
 			continue
 		}
-		sc.dirs[direction(i).opposite()] = nil
+		sc.dirs[Direction(i).Opposite()] = nil
 	}
 	delete(m.cities, name)
+	return c
+}
+
+// WriteWorldMap writes the world map using the map file format, so it can
+// be parsed again with ParseWorldMap. The map metadata and the header
+// comments are written first, followed by one line per city sorted by name.
+func WriteWorldMap(w io.Writer, m *WorldMap) error {
+	return writeWorldMap(w, m, m.sortedCities())
+}
+
+// writeWorldMap writes the world map like WriteWorldMap, with the cities in
+// the given order.
+func writeWorldMap(w io.Writer, m *WorldMap, cities []*city) error {
+	bw := bufio.NewWriter(w)
+	writeMapHeader(bw, m)
+	for _, c := range cities {
+		bw.WriteString(c.String())
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// writeMapHeader writes the metadata of the world map, sorted by key,
+// followed by its header comments.
+func writeMapHeader(bw *bufio.Writer, m *WorldMap) {
+	for _, k := range sortedAttrKeys(m.meta) {
+		bw.WriteString(fmt.Sprintf("# %s%s=%s\n", attrPrefix, k, m.meta[k]))
+	}
+	for _, comment := range m.header {
+		bw.WriteByte('#')
+		bw.WriteString(comment)
+		bw.WriteByte('\n')
+	}
+}
+
+// WriteTo writes the cities of the world map, one per line, in the order
+// set with SetCityOrder. It implements io.WriterTo.
+func (m *WorldMap) WriteTo(w io.Writer) (int64, error) {
+	if len(m.cities) == 0 {
+		n, err := io.WriteString(w, noCitiesLine+"\n")
+		return int64(n), err
+	}
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range m.orderedCities() {
+		bw.WriteString(c.String())
+		bw.WriteByte('\n')
+	}
+	err := bw.Flush()
+	return cw.n, err
 }
 
 // print prints the world map.
 //
 func (m *WorldMap) print(out io.Writer) {
-	if len(m.cities) == 0 {
-		fmt.Fprintln(out, "No cities in the map.")
-		return
-	}
+	m.WriteTo(out)
+}
+
+// sortedCities returns the cities of the map sorted by name.
+//
+func (m *WorldMap) sortedCities() []*city {
 	cs := make([]*city, 0, len(m.cities))
 	for _, c := range m.cities {
 		cs = append(cs, c)
@@ -99,27 +183,72 @@ func (m *WorldMap) print(out io.Writer) {
 	sort.SliceStable(cs, func(i, j int) bool {
 		return cs[i].name < cs[j].name
 	})
-	for _, c := range cs {
-		fmt.Fprintln(out, c)
+	return cs
+}
+
+// naturalSortedCities returns the cities of the map sorted by name in
+// natural order (C2 before C10).
+func (m *WorldMap) naturalSortedCities() []*city {
+	cs := make([]*city, 0, len(m.cities))
+	for _, c := range m.cities {
+		cs = append(cs, c)
 	}
+	sort.Slice(cs, func(i, j int) bool {
+		return naturalLess(cs[i].name, cs[j].name)
+	})
+	return cs
 }
 
 // ===============================================================
 // Utils
 // ===============================================================
 
+// countingWriter counts the bytes written to w.
+//
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer.
+//
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
 // rawCityData represents the raw city data in the map
 type rawCityData struct {
-	name string
-	dirs [4]string
+	name  string
+	dirs  [4]string
+	attrs map[string]string
+}
+
+// splitMapLineComment splits a map line into its content and its trailing
+// comment (without the '#'). Carriage returns at the end of the line are
+// removed, so CRLF files are supported.
+func splitMapLineComment(line string) (content, comment string, hasComment bool) {
+	line = strings.TrimRight(line, "\r")
+	i := strings.IndexByte(line, '#')
+	if i < 0 {
+		return line, "", false
+	}
+	return line[:i], line[i+1:], true
 }
 
 // decodeMapLine decode a single line from a map file.
 //
 func decodeMapLine(line string) (*rawCityData, error) {
-	l := strings.TrimSpace(line)
-	parts := strings.Fields(l)
-	if len(parts) > 5 {
+	content, _, _ := splitMapLineComment(line)
+	parts := strings.Fields(content)
+	numOfFields := 0
+	for _, p := range parts {
+		if !strings.HasPrefix(p, attrPrefix) {
+			numOfFields++
+		}
+	}
+	if numOfFields > 5 {
 		return nil, errors.New("Invalid map line: invalid format - wrong number of spaces")
 	}
 	d := &rawCityData{}
@@ -128,6 +257,27 @@ func decodeMapLine(line string) (*rawCityData, error) {
 		if len(m) > 2 {
 			return nil, errors.New("Invalid map line: invalid number of '=' characters")
 		}
+		// get the attributes
+		if strings.HasPrefix(p, attrPrefix) {
+			key, value := strings.TrimPrefix(m[0], attrPrefix), ""
+			if len(m) == 2 {
+				value = m[1]
+			}
+			if err := validateAttrKey(key); err != nil {
+				return nil, fmt.Errorf("Invalid map line: %v", err)
+			}
+			if err := validateAttrValue(key, value); err != nil {
+				return nil, fmt.Errorf("Invalid map line: %v", err)
+			}
+			if _, exist := d.attrs[key]; exist {
+				return nil, fmt.Errorf("Invalid map line: multiple %s attributes", m[0])
+			}
+			if d.attrs == nil {
+				d.attrs = make(map[string]string)
+			}
+			d.attrs[key] = value
+			continue
+		}
 		// get the name
 		if len(m) == 1 {
 			if d.name != "" {
@@ -137,7 +287,7 @@ func decodeMapLine(line string) (*rawCityData, error) {
 			continue
 		}
 		// find the directions
-		dir, err := directionFromString(m[0])
+		dir, err := ParseDirection(m[0])
 		if err != nil {
 			return nil, fmt.Errorf("Invalid map line: %v", err)
 		}
@@ -146,5 +296,8 @@ func decodeMapLine(line string) (*rawCityData, error) {
 		}
 		d.dirs[dir] = m[1]
 	}
+	if d.name == "" {
+		return nil, errors.New("Invalid map line: missing city name")
+	}
 	return d, nil
 }
@@ -0,0 +1,364 @@
+package invasion
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// binary map format constants
+const (
+	binaryMagic   = "AIMB"
+	binaryVersion = 1
+)
+
+// errCorruptBinaryMap is returned when a binary map cannot be read.
+var errCorruptBinaryMap = errors.New("Cannot decode the binary map: corrupt data")
+
+// EncodeBinary encodes the world map in the compact binary format. It is
+// much faster to decode than the text format, so it is meant for huge
+// generated worlds. The layout is:
+//
+// 	magic    "AIMB"
+// 	version  uvarint
+// 	strings  uvarint count, then for each string: uvarint length and bytes
+// 	comments uvarint count, then a string id per header comment
+// 	metadata uvarint count, then key and value string ids per entry
+// 	cities   uvarint count, then for each city:
+// 	           name string id
+// 	           4 neighbour slots (north, south, east, west), each one the
+// 	           city index + 1, or 0 if there is no neighbour
+// 	           uvarint count of attributes, then key and value string ids
+// 	checksum CRC-32 (IEEE) of all the previous bytes, big endian
+//
+// Cities are written in the order they were read (see OrderFile), so the
+// order is kept by DecodeBinary, and the string table has their names first,
+// so the name id of each city is its index.
+func EncodeBinary(w io.Writer, m *WorldMap) error {
+	cities := m.fileOrderedCities()
+	index := make(map[*city]uint64, len(cities))
+	strs := make([]string, 0, len(cities))
+	strIDs := make(map[string]uint64, len(cities))
+	strID := func(s string) uint64 {
+		id, ok := strIDs[s]
+		if !ok {
+			id = uint64(len(strs))
+			strIDs[s] = id
+			strs = append(strs, s)
+		}
+		return id
+	}
+	for i, c := range cities {
+		index[c] = uint64(i)
+		strID(c.name)
+	}
+	for _, c := range cities {
+		for k, v := range c.attrs {
+			strID(k)
+			strID(v)
+		}
+	}
+	for k, v := range m.meta {
+		strID(k)
+		strID(v)
+	}
+	for _, comment := range m.header {
+		strID(comment)
+	}
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf, v)
+		bw.Write(buf[:n])
+	}
+
+	bw.WriteString(binaryMagic)
+	putUvarint(binaryVersion)
+	putUvarint(uint64(len(strs)))
+	for _, s := range strs {
+		putUvarint(uint64(len(s)))
+		bw.WriteString(s)
+	}
+	putUvarint(uint64(len(m.header)))
+	for _, comment := range m.header {
+		putUvarint(strIDs[comment])
+	}
+	putUvarint(uint64(len(m.meta)))
+	for _, k := range sortedAttrKeys(m.meta) {
+		putUvarint(strIDs[k])
+		putUvarint(strIDs[m.meta[k]])
+	}
+	putUvarint(uint64(len(cities)))
+	for _, c := range cities {
+		putUvarint(strIDs[c.name])
+		for _, sc := range c.dirs {
+			if sc == nil {
+				putUvarint(0)
+			} else {
+				putUvarint(index[sc] + 1)
+			}
+		}
+		putUvarint(uint64(len(c.attrs)))
+		for _, k := range sortedAttrKeys(c.attrs) {
+			putUvarint(strIDs[k])
+			putUvarint(strIDs[c.attrs[k]])
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(buf, crc.Sum32())
+	_, err := w.Write(buf[:4])
+	return err
+}
+
+// DecodeBinary decodes a world map encoded with EncodeBinary. The checksum
+// is verified and the map is validated like in ParseWorldMap: names,
+// attributes and comments must be valid in a text map, missing reverse
+// links are filled and inconsistent links are rejected. Binary maps have
+// no lines, so WithMaxLineLength has no effect, and a city defined more
+// than once is always rejected, whatever the duplicate policy.
+func DecodeBinary(r io.Reader, opts ...ParseOption) (*WorldMap, error) {
+	cfg := newParseConfig(opts)
+	data, sum, err := readBinary(r)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode the binary map: %w", err)
+	}
+	if cfg.progress != nil {
+		cfg.progress(int64(len(data)))
+	}
+	if len(data) < len(binaryMagic)+4 || data[:len(binaryMagic)] != binaryMagic {
+		return nil, errors.New("Cannot decode the binary map: invalid header")
+	}
+	body := data[:len(data)-4]
+	if binary.BigEndian.Uint32([]byte(data[len(body):])) != sum {
+		return nil, errors.New("Cannot decode the binary map: checksum mismatch")
+	}
+
+	// all the strings are sliced from the body, so they share its memory
+	// instead of being allocated one by one
+	d := &binaryDecoder{data: body, pos: len(binaryMagic)}
+	if v := d.uvarint(); d.err == nil && v != binaryVersion {
+		return nil, fmt.Errorf("Cannot decode the binary map: unsupported version %d", v)
+	}
+
+	numOfStrs := d.count()
+	bounds := make([][2]int, numOfStrs)
+	for i := 0; i < numOfStrs && d.err == nil; i++ {
+		n := d.count()
+		bounds[i] = [2]int{d.pos, d.pos + n}
+		d.pos += n
+	}
+	str := func() string {
+		id := d.uvarint()
+		if id >= uint64(numOfStrs) {
+			d.fail()
+			return ""
+		}
+		return body[bounds[id][0]:bounds[id][1]]
+	}
+
+	wmap := &WorldMap{}
+	if n := d.count(); n > 0 {
+		wmap.header = make([]string, 0, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			comment := str()
+			if strings.ContainsAny(comment, "\r\n") {
+				return nil, fmt.Errorf("Cannot decode the binary map: comment %q has line breaks", comment)
+			}
+			wmap.header = append(wmap.header, comment)
+		}
+	}
+	if n := d.count(); n > 0 {
+		wmap.meta = make(map[string]string, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			k, v := str(), str()
+			if d.err != nil {
+				break
+			}
+			if err := validateAttr(k, v); err != nil {
+				return nil, fmt.Errorf("Cannot decode the binary map: %v", err)
+			}
+			wmap.meta[k] = v
+		}
+	}
+
+	numOfCities := d.count()
+	cs := make([]city, numOfCities)
+	wmap.cities = make(map[string]*city, numOfCities)
+	if numOfCities > 0 {
+		wmap.fileOrder = make([]string, 0, numOfCities)
+	}
+	for i := 0; i < numOfCities && d.err == nil; i++ {
+		c := &cs[i]
+		c.name = str()
+		if d.err != nil {
+			break
+		}
+		if err := validateCityName(c.name); err != nil {
+			return nil, fmt.Errorf("Cannot decode the binary map: %v", err)
+		}
+		wmap.cities[c.name] = c
+		wmap.fileOrder = append(wmap.fileOrder, c.name)
+		if len(wmap.cities) != i+1 {
+			return nil, fmt.Errorf("Cannot decode the binary map: city %s is defined more than once", c.name)
+		}
+		for dir := range c.dirs {
+			id := d.uvarint()
+			if id > uint64(numOfCities) {
+				d.fail()
+			} else if id > 0 {
+				c.dirs[dir] = &cs[id-1]
+			}
+		}
+		if n := d.count(); n > 0 {
+			c.attrs = make(map[string]string, n)
+			for j := 0; j < n && d.err == nil; j++ {
+				k, v := str(), str()
+				if d.err != nil {
+					break
+				}
+				if err := validateAttr(k, v); err != nil {
+					return nil, fmt.Errorf("Cannot decode the binary map: %v", err)
+				}
+				c.attrs[k] = v
+			}
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.pos != len(body) {
+		return nil, errCorruptBinaryMap
+	}
+
+	for i := range cs {
+		c := &cs[i]
+		for dir, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			if sc == c {
+				return nil, fmt.Errorf("Cannot parse the map: city %s is connected to itself at its %s", c.name, Direction(dir))
+			}
+			opposite := Direction(dir).Opposite()
+			if sc.dirs[opposite] == nil {
+				sc.dirs[opposite] = c
+			} else if sc.dirs[opposite] != c {
+				return nil, errInconsistentMap
+			}
+		}
+	}
+	return wmap, nil
+}
+
+// validateAttr checks that an attribute or metadata entry can be written in
+// a map line.
+func validateAttr(key, value string) error {
+	if err := validateAttrKey(key); err != nil {
+		return err
+	}
+	return validateAttrValue(key, value)
+}
+
+// readBinary reads all the data of a binary map from r, and returns it with
+// the CRC-32 of all its bytes but the last 4 ones, which have the checksum.
+// The data is read as a string, so its memory is shared by the strings of
+// the map. If the size of the data is known (e.g. r is a file or a
+// bytes.Reader), the string is allocated only once.
+func readBinary(r io.Reader) (string, uint32, error) {
+	var sb strings.Builder
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		sb.Grow(v.Len())
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if fi, err := v.Stat(); err == nil && fi.Mode().IsRegular() {
+			sb.Grow(int(fi.Size()))
+		}
+	}
+	crc := &crcWriter{}
+	_, err := io.Copy(io.MultiWriter(&sb, crc), r)
+	return sb.String(), crc.sum, err
+}
+
+// crcWriter computes the CRC-32 of the bytes written to it, except the last
+// 4 ones, which are held back until more bytes are written.
+type crcWriter struct {
+	sum     uint32
+	held    [4]byte
+	numHeld int
+}
+
+// Write updates the checksum with p.
+//
+func (w *crcWriter) Write(p []byte) (int, error) {
+	if w.numHeld+len(p) <= len(w.held) {
+		w.numHeld += copy(w.held[w.numHeld:], p)
+		return len(p), nil
+	}
+	if len(p) >= len(w.held) {
+		w.sum = crc32.Update(w.sum, crc32.IEEETable, w.held[:w.numHeld])
+		w.sum = crc32.Update(w.sum, crc32.IEEETable, p[:len(p)-len(w.held)])
+		w.numHeld = copy(w.held[:], p[len(p)-len(w.held):])
+		return len(p), nil
+	}
+	// only some of the held bytes are released
+	n := w.numHeld + len(p) - len(w.held)
+	w.sum = crc32.Update(w.sum, crc32.IEEETable, w.held[:n])
+	copy(w.held[:], w.held[n:w.numHeld])
+	copy(w.held[w.numHeld-n:], p)
+	w.numHeld = len(w.held)
+	return len(p), nil
+}
+
+// binaryDecoder reads the values of a binary map. After the first error,
+// all the reads return zero values and err holds the error.
+type binaryDecoder struct {
+	data string
+	pos  int
+	err  error
+}
+
+// uvarint reads an unsigned varint. It is simpler than binary.Uvarint so
+// it is inlined, as it is called several times per city: the values cannot
+// be greater than 2^63-1, which is more than enough for any count or id.
+func (d *binaryDecoder) uvarint() uint64 {
+	var v uint64
+	for shift := uint(0); shift < 63 && d.pos < len(d.data); shift += 7 {
+		b := d.data[d.pos]
+		d.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+	d.fail()
+	return 0
+}
+
+// count reads an unsigned varint used as a number of elements, which cannot
+// be greater than the remaining bytes.
+func (d *binaryDecoder) count() int {
+	v := d.uvarint()
+	if v > uint64(len(d.data)-d.pos) {
+		d.fail()
+		return 0
+	}
+	return int(v)
+}
+
+// fail marks the data as corrupt, and skips the remaining data so the next
+// reads fail too.
+func (d *binaryDecoder) fail() {
+	if d.err == nil {
+		d.err = errCorruptBinaryMap
+	}
+	d.pos = len(d.data)
+}
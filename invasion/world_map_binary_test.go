@@ -0,0 +1,184 @@
+package invasion
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"testing/iotest"
+
+	"github.com/fpabl0/saga-alien-invasion/mapgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_Binary(t *testing.T) {
+
+	t.Run("round trip", func(t *testing.T) {
+		wm, err := parseMapString(`# @author=qa
+# Small map
+C1 south=C4 east=C2 @defense=3 @terrain=mountain
+C2 south=C5 east=C3 west=C1 @terrain=mountain
+C3 south=C6 west=C2
+C4 north=C1
+C5 north=C2
+C6 north=C3
+C7
+`)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, wm))
+		assert.Equal(t, "AIMB", buf.String()[:4])
+
+		wm2, err := DecodeBinary(buf)
+		require.NoError(t, err)
+		assert.Equal(t, wm, wm2)
+	})
+
+	t.Run("round trip normal map", func(t *testing.T) {
+		wm := parseNormalMap(t)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, wm))
+		wm2, err := DecodeBinary(buf)
+		require.NoError(t, err)
+		assert.Equal(t, wm, wm2)
+	})
+
+	t.Run("read in small chunks", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, wm))
+		data := buf.Bytes()
+
+		wm2, err := DecodeBinary(iotest.OneByteReader(bytes.NewReader(data)))
+		require.NoError(t, err)
+		assert.Equal(t, wm, wm2)
+		wm2, err = DecodeBinary(iotest.HalfReader(bytes.NewReader(data)))
+		require.NoError(t, err)
+		assert.Equal(t, wm, wm2)
+
+		corrupt := append([]byte{}, data...)
+		corrupt[len(corrupt)-5]++
+		_, err = DecodeBinary(iotest.HalfReader(bytes.NewReader(corrupt)))
+		assert.EqualError(t, err, "Cannot decode the binary map: checksum mismatch")
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, &WorldMap{cities: map[string]*city{}}))
+		wm, err := DecodeBinary(buf)
+		require.NoError(t, err)
+		assert.Empty(t, wm.cities)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, wm))
+		data := buf.Bytes()
+
+		_, err := DecodeBinary(bytes.NewReader([]byte("C1 south=C4 east=C2\n")))
+		assert.EqualError(t, err, "Cannot decode the binary map: invalid header")
+
+		corrupt := append([]byte{}, data...)
+		corrupt[10]++
+		_, err = DecodeBinary(bytes.NewReader(corrupt))
+		assert.EqualError(t, err, "Cannot decode the binary map: checksum mismatch")
+
+		_, err = DecodeBinary(bytes.NewReader(withChecksum(data[:len(data)-10])))
+		assert.EqualError(t, err, "Cannot decode the binary map: corrupt data")
+
+		_, err = DecodeBinary(bytes.NewReader(withChecksum([]byte("AIMB\x02"))))
+		assert.EqualError(t, err, "Cannot decode the binary map: unsupported version 2")
+	})
+
+	t.Run("inconsistent links", func(t *testing.T) {
+		// A north=B, B south=C
+		data := []byte("AIMB\x01\x03\x01A\x01B\x01C\x00\x00\x03" +
+			"\x00\x02\x00\x00\x00\x00" +
+			"\x01\x00\x03\x00\x00\x00" +
+			"\x02\x00\x00\x00\x00\x00")
+		_, err := DecodeBinary(bytes.NewReader(withChecksum(data)))
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+
+		// A north=B, missing reverse links are filled
+		data = []byte("AIMB\x01\x02\x01A\x01B\x00\x00\x02" +
+			"\x00\x02\x00\x00\x00\x00" +
+			"\x01\x00\x00\x00\x00\x00")
+		wm, err := DecodeBinary(bytes.NewReader(withChecksum(data)))
+		require.NoError(t, err)
+		assert.Equal(t, "B south=A", wm.cities["B"].String())
+	})
+
+	t.Run("city connected to itself", func(t *testing.T) {
+		// A north=A
+		data := []byte("AIMB\x01\x01\x01A\x00\x00\x01" +
+			"\x00\x01\x00\x00\x00\x00")
+		_, err := DecodeBinary(bytes.NewReader(withChecksum(data)))
+		assert.EqualError(t, err, "Cannot parse the map: city A is connected to itself at its north")
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		// a city named "A B"
+		data := []byte("AIMB\x01\x01\x03A B\x00\x00\x01" +
+			"\x00\x00\x00\x00\x00\x00")
+		_, err := DecodeBinary(bytes.NewReader(withChecksum(data)))
+		assert.EqualError(t, err, `Cannot decode the binary map: "A B" is not a valid city name`)
+
+		// a city A with the attribute @x=y z
+		data = []byte("AIMB\x01\x03\x01A\x01x\x03y z\x00\x00\x01" +
+			"\x00\x00\x00\x00\x00\x01\x01\x02")
+		_, err = DecodeBinary(bytes.NewReader(withChecksum(data)))
+		assert.EqualError(t, err, `Cannot decode the binary map: attribute @x has an invalid value "y z"`)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, parseSmallMap(t)))
+		size := int64(buf.Len())
+		var read int64
+		_, err := DecodeBinary(buf, WithProgress(func(n int64) { read = n }))
+		require.NoError(t, err)
+		assert.Equal(t, size, read)
+	})
+}
+
+func BenchmarkParseWorldMap(b *testing.B) {
+	data := mapgen.NewGenerator(400, 300).Generate()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseWorldMap(bufio.NewScanner(bytes.NewReader(data))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBinary(b *testing.B) {
+	wm, err := ParseWorldMap(bufio.NewScanner(bytes.NewReader(mapgen.NewGenerator(400, 300).Generate())))
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := EncodeBinary(buf, wm); err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeBinary(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// ===============================================================
+// test utils
+// ===============================================================
+
+func withChecksum(data []byte) []byte {
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(data))
+	return append(append([]byte{}, data...), sum...)
+}
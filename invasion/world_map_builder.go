@@ -0,0 +1,217 @@
+package invasion
+
+import (
+	"fmt"
+	"os"
+)
+
+// DuplicatePolicy defines how a city defined in more than one map line
+// is handled while parsing a world map.
+type DuplicatePolicy int
+
+// duplicate policy options
+const (
+	// DuplicateMerge merges all the definitions of the same city. If two
+	// definitions link the same direction to different cities, the map is
+	// rejected. This is the default policy.
+	DuplicateMerge DuplicatePolicy = iota
+	// DuplicateError rejects any city defined more than once.
+	DuplicateError
+	// DuplicateLastWins lets the last definition override the conflicting
+	// links of the previous ones. A warning is reported for each overridden link.
+	DuplicateLastWins
+)
+
+// String implements fmt.Stringer.
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateMerge:
+		return "merge"
+	case DuplicateError:
+		return "error"
+	case DuplicateLastWins:
+		return "last-wins"
+	}
+	return "invalid duplicate policy"
+}
+
+// ParseDuplicatePolicy converts a policy name ("merge", "error" or "last-wins")
+// into a DuplicatePolicy. If the name is not valid this will return an error.
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch s {
+	case "merge":
+		return DuplicateMerge, nil
+	case "error":
+		return DuplicateError, nil
+	case "last-wins":
+		return DuplicateLastWins, nil
+	}
+	return -1, fmt.Errorf("%s is not a valid duplicate policy", s)
+}
+
+// ParseOption configures how a world map is parsed.
+type ParseOption func(*parseConfig)
+
+// WithDuplicatePolicy sets the policy used when a city is defined in more
+// than one line.
+func WithDuplicatePolicy(p DuplicatePolicy) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.duplicates = p
+	}
+}
+
+// WithWarningHandler sets the function that receives the parser warnings.
+// By default warnings are written to STDERR.
+func WithWarningHandler(fn func(msg string)) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.warn = fn
+	}
+}
+
+// parseConfig holds the options used while parsing a world map.
+type parseConfig struct {
+	duplicates    DuplicatePolicy
+	warn          func(msg string)
+	maxLineLength int
+	progress      func(bytesRead int64)
+}
+
+// newParseConfig creates a parse config with the default values and
+// applies the given options.
+func newParseConfig(opts []ParseOption) parseConfig {
+	cfg := parseConfig{
+		duplicates:    DuplicateMerge,
+		maxLineLength: DefaultMaxLineLength,
+		warn: func(msg string) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.warn == nil {
+		cfg.warn = func(string) {}
+	}
+	return cfg
+}
+
+// mapBuilder builds a world map from raw city definitions, validating
+// that the links between cities are consistent.
+type mapBuilder struct {
+	wmap    *WorldMap
+	cfg     parseConfig
+	defined map[string]struct{}
+	// referenced has the cities found as surrounding cities, in the order
+	// they were first referenced.
+	referenced []string
+}
+
+// newMapBuilder creates a new map builder with an empty world map.
+//
+func newMapBuilder(opts []ParseOption) *mapBuilder {
+	return &mapBuilder{
+		wmap:    &WorldMap{cities: make(map[string]*city)},
+		cfg:     newParseConfig(opts),
+		defined: make(map[string]struct{}),
+	}
+}
+
+// addCity adds a city definition to the map being built. Each surrounding
+// city gets the opposite link automatically.
+func (b *mapBuilder) addCity(data *rawCityData) error {
+	_, duplicated := b.defined[data.name]
+	if duplicated && b.cfg.duplicates == DuplicateError {
+		return fmt.Errorf("Cannot parse the map: city %s is defined more than once", data.name)
+	}
+	if !duplicated {
+		b.wmap.fileOrder = append(b.wmap.fileOrder, data.name)
+	}
+	b.defined[data.name] = struct{}{}
+
+	curCity := b.wmap.getOrCreateCity(data.name)
+	for i, d := range data.dirs {
+		if d == "" {
+			continue
+		}
+		dir := Direction(i)
+		if d == data.name {
+			return fmt.Errorf("Cannot parse the map: city %s is connected to itself at its %s", d, dir)
+		}
+		if _, exist := b.wmap.cities[d]; !exist {
+			b.referenced = append(b.referenced, d)
+		}
+		surCity := b.wmap.getOrCreateCity(d)
+		if prev := curCity.dirs[dir]; prev != nil && prev != surCity {
+			if !duplicated {
+				return errInconsistentMap
+			}
+			if b.cfg.duplicates != DuplicateLastWins {
+				return fmt.Errorf("Cannot parse the map: conflicting definitions of city %s (%s=%s and %s=%s)",
+					data.name, dir, prev.name, dir, surCity.name)
+			}
+			b.cfg.warn(fmt.Sprintf("city %s is defined more than once, %s=%s overrides %s=%s",
+				data.name, dir, surCity.name, dir, prev.name))
+			if prev.dirs[dir.Opposite()] == curCity {
+				prev.dirs[dir.Opposite()] = nil
+			}
+		}
+		curCity.dirs[dir] = surCity
+		oppositeDir := dir.Opposite()
+		if surCity.dirs[oppositeDir] == nil {
+			surCity.dirs[oppositeDir] = curCity
+		} else if surCity.dirs[oppositeDir] != curCity {
+			return errInconsistentMap
+		}
+	}
+	for _, k := range sortedAttrKeys(data.attrs) {
+		v := data.attrs[k]
+		if prev, ok := curCity.attrs[k]; ok && prev != v {
+			if b.cfg.duplicates != DuplicateLastWins {
+				return fmt.Errorf("Cannot parse the map: conflicting definitions of city %s (%s%s=%s and %s%s=%s)",
+					data.name, attrPrefix, k, prev, attrPrefix, k, v)
+			}
+			b.cfg.warn(fmt.Sprintf("city %s is defined more than once, %s%s=%s overrides %s%s=%s",
+				data.name, attrPrefix, k, v, attrPrefix, k, prev))
+		}
+		if curCity.attrs == nil {
+			curCity.attrs = make(map[string]string, len(data.attrs))
+		}
+		curCity.attrs[k] = v
+	}
+	return nil
+}
+
+// addComment adds a comment line to the map header. Comments found after
+// the first city definition are not part of the header and are dropped.
+// Header comments in the form `@key=value` are added as map metadata.
+func (b *mapBuilder) addComment(comment string) {
+	if len(b.defined) > 0 {
+		return
+	}
+	if key, value, ok := decodeMetadataComment(comment); ok {
+		b.setMetadata(key, value)
+		return
+	}
+	b.wmap.header = append(b.wmap.header, comment)
+}
+
+// setMetadata sets a metadata entry of the map being built.
+//
+func (b *mapBuilder) setMetadata(key, value string) {
+	if b.wmap.meta == nil {
+		b.wmap.meta = make(map[string]string)
+	}
+	b.wmap.meta[key] = value
+}
+
+// build returns the built world map.
+//
+func (b *mapBuilder) build() *WorldMap {
+	// the cities that are only referenced go after the defined ones
+	for _, name := range b.referenced {
+		if _, ok := b.defined[name]; !ok {
+			b.wmap.fileOrder = append(b.wmap.fileOrder, name)
+		}
+	}
+	return b.wmap
+}
@@ -0,0 +1,103 @@
+package invasion
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_DuplicatePolicy(t *testing.T) {
+
+	compatibleMap := `C1 south=C3
+C2 west=C1
+C1 east=C2
+C3 north=C1
+`
+	conflictingMap := `C1 east=C2 south=C3
+C2 west=C1
+C1 east=C4
+C3 north=C1
+`
+
+	t.Run("merge compatible definitions", func(t *testing.T) {
+		wm, err := parseMapString(compatibleMap)
+		require.NoError(t, err)
+		assert.Len(t, wm.cities, 3)
+		assert.Equal(t, "C1 south=C3 east=C2", wm.cities["C1"].String())
+		assert.Equal(t, "C2 west=C1", wm.cities["C2"].String())
+		assert.Equal(t, "C3 north=C1", wm.cities["C3"].String())
+	})
+
+	t.Run("merge conflicting definitions", func(t *testing.T) {
+		wm, err := parseMapString(conflictingMap, WithDuplicatePolicy(DuplicateMerge))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: conflicting definitions of city C1 (east=C2 and east=C4)")
+	})
+
+	t.Run("error on any duplicated definition", func(t *testing.T) {
+		wm, err := parseMapString(compatibleMap, WithDuplicatePolicy(DuplicateError))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: city C1 is defined more than once")
+	})
+
+	t.Run("last definition wins", func(t *testing.T) {
+		var warnings []string
+		wm, err := parseMapString(conflictingMap,
+			WithDuplicatePolicy(DuplicateLastWins),
+			WithWarningHandler(func(msg string) { warnings = append(warnings, msg) }),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"city C1 is defined more than once, east=C4 overrides east=C2",
+		}, warnings)
+		assert.Equal(t, "C1 south=C3 east=C4", wm.cities["C1"].String())
+		assert.Equal(t, "C2", wm.cities["C2"].String())
+		assert.Equal(t, "C4 west=C1", wm.cities["C4"].String())
+	})
+
+	t.Run("last definition wins without conflicts", func(t *testing.T) {
+		var warnings []string
+		wm, err := parseMapString(compatibleMap,
+			WithDuplicatePolicy(DuplicateLastWins),
+			WithWarningHandler(func(msg string) { warnings = append(warnings, msg) }),
+		)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.Equal(t, "C1 south=C3 east=C2", wm.cities["C1"].String())
+	})
+
+	t.Run("links inconsistent with other cities are rejected", func(t *testing.T) {
+		wm, err := parseMapString("C2 west=C1\nC1 east=C3\n", WithDuplicatePolicy(DuplicateLastWins))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+	})
+}
+
+func TestDuplicatePolicy_String(t *testing.T) {
+	assert.Equal(t, "merge", DuplicateMerge.String())
+	assert.Equal(t, "error", DuplicateError.String())
+	assert.Equal(t, "last-wins", DuplicateLastWins.String())
+	assert.Equal(t, "invalid duplicate policy", DuplicatePolicy(-1).String())
+}
+
+func TestDuplicatePolicy_Parse(t *testing.T) {
+	for _, p := range []DuplicatePolicy{DuplicateMerge, DuplicateError, DuplicateLastWins} {
+		got, err := ParseDuplicatePolicy(p.String())
+		assert.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+
+	_, err := ParseDuplicatePolicy("first-wins")
+	assert.EqualError(t, err, "first-wins is not a valid duplicate policy")
+}
+
+// ===============================================================
+// test utils
+// ===============================================================
+
+func parseMapString(data string, opts ...ParseOption) (*WorldMap, error) {
+	return ParseWorldMap(bufio.NewScanner(strings.NewReader(data)), opts...)
+}
@@ -0,0 +1,267 @@
+package invasion
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Codec represents a world map file format.
+type Codec struct {
+	// Name identifies the format, e.g. "text" or "json".
+	Name string
+	// Extensions has the file extensions of the format, with the leading dot.
+	Extensions []string
+	// Magic has the first bytes of the files of this format. It can be
+	// empty if the format has no magic bytes.
+	Magic []byte
+	// Decode decodes a world map.
+	Decode func(r io.Reader, opts ...ParseOption) (*WorldMap, error)
+	// Encode encodes a world map.
+	Encode func(w io.Writer, m *WorldMap) error
+	// Lost describes the data of the map that Encode cannot write, e.g.
+	// "2 attributes". It is nil for the formats that keep everything.
+	Lost func(m *WorldMap) []string
+}
+
+// gzipExt is the extension of the gzip compressed map files.
+const gzipExt = ".gz"
+
+// gzipMagic has the first bytes of any gzip file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// codecs has the registered codecs, in registration order.
+var codecs = []*Codec{
+	{
+		Name:       "text",
+		Extensions: []string{".txt", ".map"},
+		Decode:     ReadWorldMap,
+		Encode:     WriteWorldMap,
+	},
+	{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Magic:      []byte("{"),
+		Decode:     DecodeJSON,
+		Encode:     EncodeJSON,
+	},
+	{
+		Name:       "csv",
+		Extensions: []string{".csv"},
+		Magic:      []byte(strings.Join(csvEdgesHeader, ",")),
+		Decode: func(r io.Reader, opts ...ParseOption) (*WorldMap, error) {
+			return DecodeCSV(r, nil, opts...)
+		},
+		Encode: EncodeEdgeListCSV,
+		Lost:   csvLostData,
+	},
+	{
+		Name:       "binary",
+		Extensions: []string{".bin"},
+		Magic:      []byte(binaryMagic),
+		Decode:     DecodeBinary,
+		Encode:     EncodeBinary,
+	},
+}
+
+// RegisterCodec registers a new world map format. The name cannot be
+// already registered.
+func RegisterCodec(c Codec) error {
+	if c.Name == "" || c.Decode == nil || c.Encode == nil {
+		return fmt.Errorf("codec must have a name, a decode and an encode function")
+	}
+	if _, ok := LookupCodec(c.Name); ok {
+		return fmt.Errorf("codec %s is already registered", c.Name)
+	}
+	codecs = append(codecs, &c)
+	return nil
+}
+
+// LookupCodec returns the codec registered with the given name.
+//
+func LookupCodec(name string) (*Codec, bool) {
+	for _, c := range codecs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// CodecNames returns the names of the registered codecs.
+//
+func CodecNames() []string {
+	names := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// CodecForFile returns the codec used to write the file. The codec is
+// chosen by its name if format is not empty, or by the file extension
+// (ignoring a trailing .gz) otherwise. Files with unknown extensions are
+// written in the text format.
+func CodecForFile(fname, format string) (*Codec, error) {
+	if format != "" {
+		c, ok := LookupCodec(format)
+		if !ok {
+			return nil, fmt.Errorf("unknown map format %q, valid formats are: %s", format, strings.Join(CodecNames(), ", "))
+		}
+		return c, nil
+	}
+	if c := codecByExtension(fname); c != nil {
+		return c, nil
+	}
+	return codecs[0], nil
+}
+
+// DetectCodec returns the codec used to read a file whose first bytes are
+// head. The codec is chosen by its name if format is not empty, then by the
+// file extension (ignoring a trailing .gz) and then by the magic bytes.
+// Files that cannot be detected are read in the text format.
+func DetectCodec(fname, format string, head []byte) (*Codec, error) {
+	if format != "" || codecByExtension(fname) != nil {
+		return CodecForFile(fname, format)
+	}
+	head = bytes.TrimLeft(head, " \t\r\n")
+	for _, c := range codecs {
+		if len(c.Magic) > 0 && bytes.HasPrefix(head, c.Magic) {
+			return c, nil
+		}
+	}
+	return codecs[0], nil
+}
+
+// OpenMapFile opens a map file for reading. Gzip compressed files are
+// decompressed transparently.
+func OpenMapFile(fname string) (io.ReadCloser, error) {
+	return openMapFile(fname, nil)
+}
+
+// openMapFile opens a map file like OpenMapFile. If progress is not nil, it
+// receives the number of bytes read from the file (before decompressing it).
+func openMapFile(fname string, progress func(bytesRead int64)) (io.ReadCloser, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	if progress != nil {
+		r = &progressReader{r: f, report: progress}
+	}
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(gzipMagic))
+	if !bytes.Equal(head, gzipMagic) {
+		return &mapFile{Reader: br, closers: []io.Closer{f}}, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &mapFile{Reader: zr, closers: []io.Closer{zr, f}}, nil
+}
+
+// CreateMapFile creates a map file for writing. If the file name ends with
+// .gz, the written data is gzip compressed.
+func CreateMapFile(fname string) (io.WriteCloser, error) {
+	f, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(f)
+	if !strings.HasSuffix(fname, gzipExt) {
+		return &mapFile{Writer: bw, flusher: bw, closers: []io.Closer{f}}, nil
+	}
+	zw := gzip.NewWriter(bw)
+	return &mapFile{Writer: zw, flusher: bw, closers: []io.Closer{zw, f}}, nil
+}
+
+// ReadWorldMapFile reads a world map file. The format is detected like in
+// DetectCodec and gzip compressed files are supported. The progress set with
+// WithProgress is reported for any format, counting the bytes of the file.
+func ReadWorldMapFile(fname, format string, opts ...ParseOption) (*WorldMap, error) {
+	f, err := openMapFile(fname, newParseConfig(opts).progress)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(64)
+	c, err := DetectCodec(fname, format, head)
+	if err != nil {
+		return nil, err
+	}
+	// the progress is already reported while reading the file
+	return c.Decode(br, append(opts[:len(opts):len(opts)], WithProgress(nil))...)
+}
+
+// WriteWorldMapFile writes a world map file. The format is chosen like in
+// CodecForFile and the file is gzip compressed if its name ends with .gz.
+func WriteWorldMapFile(fname, format string, m *WorldMap) error {
+	c, err := CodecForFile(fname, format)
+	if err != nil {
+		return err
+	}
+	f, err := CreateMapFile(fname)
+	if err != nil {
+		return err
+	}
+	if err := c.Encode(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// codecByExtension returns the codec of the file extension, ignoring a
+// trailing .gz. If there is no codec for the extension, this returns nil.
+func codecByExtension(fname string) *Codec {
+	ext := filepath.Ext(strings.TrimSuffix(fname, gzipExt))
+	for _, c := range codecs {
+		for _, e := range c.Extensions {
+			if strings.EqualFold(e, ext) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// mapFile is a map file opened with OpenMapFile or CreateMapFile.
+type mapFile struct {
+	io.Reader
+	io.Writer
+	// flusher is flushed before closing the file, after closing the gzip
+	// writer (if any).
+	flusher *bufio.Writer
+	closers []io.Closer
+}
+
+// Close closes the gzip reader or writer (if any) and the file.
+//
+func (f *mapFile) Close() error {
+	var err error
+	for i, c := range f.closers {
+		// the file is the last closer
+		if i == len(f.closers)-1 && f.flusher != nil {
+			if ferr := f.flusher.Flush(); err == nil {
+				err = ferr
+			}
+		}
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package invasion
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_Codecs(t *testing.T) {
+
+	t.Run("write and read every format", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		require.NoError(t, wm.SetCityAttribute("C1", "defense", "3"))
+		dir := t.TempDir()
+		for _, fname := range []string{
+			"map.txt", "map.json", "map.bin",
+			"map.txt.gz", "map.json.gz", "map.bin.gz",
+		} {
+			fpath := filepath.Join(dir, fname)
+			require.NoError(t, WriteWorldMapFile(fpath, "", wm), fname)
+			got, err := ReadWorldMapFile(fpath, "")
+			require.NoError(t, err, fname)
+			assert.Equal(t, wm.cities, got.cities, fname)
+		}
+	})
+
+	t.Run("csv edge list", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		fpath := filepath.Join(t.TempDir(), "map.csv")
+		require.NoError(t, WriteWorldMapFile(fpath, "", wm))
+		data, err := os.ReadFile(fpath)
+		require.NoError(t, err)
+		assert.Equal(t, "from,direction,to\nC1,south,C4\n", string(data[:30]))
+		got, err := ReadWorldMapFile(fpath, "")
+		require.NoError(t, err)
+		assert.Equal(t, wm.cities, got.cities)
+	})
+
+	t.Run("explicit format and magic bytes detection", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		dir := t.TempDir()
+		for _, format := range []string{"text", "json", "csv", "binary"} {
+			// unknown extensions are detected by their magic bytes
+			fpath := filepath.Join(dir, "map-"+format+".data")
+			require.NoError(t, WriteWorldMapFile(fpath, format, wm), format)
+			got, err := ReadWorldMapFile(fpath, "")
+			require.NoError(t, err, format)
+			assert.Equal(t, wm.cities, got.cities, format)
+
+			// gzip files are detected by their magic bytes too
+			fpath = filepath.Join(dir, "map-"+format+".gz")
+			require.NoError(t, WriteWorldMapFile(fpath, format, wm), format)
+			got, err = ReadWorldMapFile(fpath, format)
+			require.NoError(t, err, format)
+			assert.Equal(t, wm.cities, got.cities, format)
+		}
+	})
+
+	t.Run("gzip files are compressed", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "map.txt.gz")
+		require.NoError(t, WriteWorldMapFile(fpath, "", parseSmallMap(t)))
+		data, err := os.ReadFile(fpath)
+		require.NoError(t, err)
+		assert.Equal(t, gzipMagic, data[:2])
+
+		f, err := OpenMapFile(fpath)
+		require.NoError(t, err)
+		defer f.Close()
+		text, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "C1 south=C4 east=C2\n", string(text[:20]))
+	})
+
+	t.Run("progress counts the file bytes", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "map.json.gz")
+		require.NoError(t, WriteWorldMapFile(fpath, "", parseSmallMap(t)))
+		fi, err := os.Stat(fpath)
+		require.NoError(t, err)
+
+		var reports []int64
+		_, err = ReadWorldMapFile(fpath, "", WithProgress(func(n int64) {
+			reports = append(reports, n)
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []int64{fi.Size()}, reports)
+	})
+
+	t.Run("codec selection", func(t *testing.T) {
+		c, err := CodecForFile("map.JSON", "")
+		require.NoError(t, err)
+		assert.Equal(t, "json", c.Name)
+
+		c, err = CodecForFile("map.unknown", "")
+		require.NoError(t, err)
+		assert.Equal(t, "text", c.Name)
+
+		c, err = CodecForFile("map.json", "binary")
+		require.NoError(t, err)
+		assert.Equal(t, "binary", c.Name)
+
+		_, err = CodecForFile("map.json", "yaml")
+		assert.EqualError(t, err, `unknown map format "yaml", valid formats are: text, json, csv, binary`)
+
+		c, err = DetectCodec("map", "", []byte("  \n{\"cities\": []}"))
+		require.NoError(t, err)
+		assert.Equal(t, "json", c.Name)
+
+		c, err = DetectCodec("map.txt", "", []byte("AIMB"))
+		require.NoError(t, err)
+		assert.Equal(t, "text", c.Name)
+	})
+
+	t.Run("register codec", func(t *testing.T) {
+		defer func(saved []*Codec) { codecs = saved }(codecs)
+
+		err := RegisterCodec(Codec{Name: "json", Decode: DecodeJSON, Encode: EncodeJSON})
+		assert.EqualError(t, err, "codec json is already registered")
+
+		err = RegisterCodec(Codec{Name: "incomplete"})
+		assert.EqualError(t, err, "codec must have a name, a decode and an encode function")
+
+		require.NoError(t, RegisterCodec(Codec{Name: "json2", Extensions: []string{".json2"}, Decode: DecodeJSON, Encode: EncodeJSON}))
+		c, err := CodecForFile("map.json2", "")
+		require.NoError(t, err)
+		assert.Equal(t, "json2", c.Name)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ReadWorldMapFile(filepath.Join(t.TempDir(), "not-exist.txt"), "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,186 @@
+package invasion
+
+import (
+	"sort"
+)
+
+// CriticalCity represents a city whose destruction splits the map.
+type CriticalCity struct {
+	Name string
+	// Parts is the number of parts its connected component is split into
+	// when the city is destroyed.
+	Parts int
+	// Disconnected is the number of cities that are no longer connected to
+	// the biggest of those parts.
+	Disconnected int
+}
+
+// ArticulationPoints returns the cities whose destruction increases the
+// number of connected components of the map, sorted by name.
+func (m *WorldMap) ArticulationPoints() []string {
+	cuts := m.analyzeCuts()
+	names := make([]string, 0, len(cuts.critical))
+	for _, cc := range cuts.critical {
+		names = append(names, cc.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bridges returns the roads whose removal increases the number of connected
+// components of the map. Roads are returned like in Roads.
+func (m *WorldMap) Bridges() []Road {
+	return m.analyzeCuts().bridges
+}
+
+// CriticalCities returns the articulation points of the map ranked by how
+// much of the map they disconnect: first by the number of disconnected
+// cities, then by the number of parts and then by name in natural order.
+func (m *WorldMap) CriticalCities() []CriticalCity {
+	critical := m.analyzeCuts().critical
+	sort.Slice(critical, func(i, j int) bool {
+		a, b := critical[i], critical[j]
+		if a.Disconnected != b.Disconnected {
+			return a.Disconnected > b.Disconnected
+		}
+		if a.Parts != b.Parts {
+			return a.Parts > b.Parts
+		}
+		return naturalLess(a.Name, b.Name)
+	})
+	return critical
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// cutAnalysis holds the articulation points and bridges of a map.
+//
+type cutAnalysis struct {
+	critical []CriticalCity
+	bridges  []Road
+}
+
+// cutFrame is a city being visited by the depth-first search of
+// analyzeCuts.
+type cutFrame struct {
+	v int
+	// from is the direction of the road used to reach the city, from the
+	// parent point of view, or -1 for the root.
+	from Direction
+	// next is the next direction to explore.
+	next int
+}
+
+// analyzeCuts finds the articulation points and bridges of the map using
+// the Hopcroft-Tarjan algorithm. The depth-first search is iterative, so
+// huge maps do not overflow the stack. Roads are identified by direction,
+// so two cities linked by two different roads are handled correctly.
+func (m *WorldMap) analyzeCuts() *cutAnalysis {
+	cities := m.sortedCities()
+	index := make(map[*city]int, len(cities))
+	for i, c := range cities {
+		index[c] = i
+	}
+
+	n := len(cities)
+	disc := make([]int, n) // discovery time + 1, 0 means not visited
+	low := make([]int, n)
+	size := make([]int, n) // size of the depth-first subtree
+	// parts separated from each city when it is destroyed
+	sepCount := make([]int, n)
+	sepSum := make([]int, n)
+	sepMax := make([]int, n)
+
+	res := &cutAnalysis{}
+	time := 0
+	for root := range cities {
+		if disc[root] != 0 {
+			continue
+		}
+		var visited []int
+		stack := []cutFrame{{v: root, from: -1}}
+		time++
+		disc[root], low[root], size[root] = time, time, 1
+		visited = append(visited, root)
+
+		for len(stack) > 0 {
+			f := &stack[len(stack)-1]
+			c := cities[f.v]
+			if f.next < len(c.dirs) {
+				dir := Direction(f.next)
+				f.next++
+				sc := c.dirs[dir]
+				// skip missing roads, loops and the road to the parent
+				if sc == nil || sc == c || (f.from >= 0 && dir == f.from.Opposite()) {
+					continue
+				}
+				u := index[sc]
+				if disc[u] != 0 {
+					low[f.v] = minInt(low[f.v], disc[u])
+					continue
+				}
+				time++
+				disc[u], low[u], size[u] = time, time, 1
+				visited = append(visited, u)
+				stack = append(stack, cutFrame{v: u, from: dir})
+				continue
+			}
+
+			// the city is finished, update its parent
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				break
+			}
+			u, p := f.v, stack[len(stack)-1].v
+			low[p] = minInt(low[p], low[u])
+			size[p] += size[u]
+			if low[u] > disc[p] {
+				res.bridges = append(res.bridges, newRoad(cities[p], f.from, cities[u]))
+			}
+			if low[u] >= disc[p] {
+				sepCount[p]++
+				sepSum[p] += size[u]
+				sepMax[p] = maxInt(sepMax[p], size[u])
+			}
+		}
+
+		compSize := size[root]
+		for _, v := range visited {
+			// the root is separated from all its children, so it only
+			// splits the map if it has more than one
+			if sepCount[v] == 0 || (v == root && sepCount[v] < 2) {
+				continue
+			}
+			rest := compSize - 1 - sepSum[v]
+			parts := sepCount[v]
+			if rest > 0 {
+				parts++
+			}
+			res.critical = append(res.critical, CriticalCity{
+				Name:         cities[v].name,
+				Parts:        parts,
+				Disconnected: compSize - 1 - maxInt(sepMax[v], rest),
+			})
+		}
+	}
+
+	sort.Slice(res.bridges, func(i, j int) bool {
+		a, b := res.bridges[i], res.bridges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.Dir < b.Dir
+	})
+	return res
+}
+
+// newRoad creates the road between two cities, from the city that has the
+// other one at its south or east.
+func newRoad(from *city, dir Direction, to *city) Road {
+	if dir == North || dir == West {
+		return Road{From: to.name, Dir: dir.Opposite(), To: from.name}
+	}
+	return Road{From: from.name, Dir: dir, To: to.name}
+}
@@ -0,0 +1,60 @@
+package invasion
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_CriticalCities(t *testing.T) {
+
+	t.Run("grid without critical cities", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		assert.Empty(t, wm.ArticulationPoints())
+		assert.Empty(t, wm.Bridges())
+		assert.Empty(t, wm.CriticalCities())
+	})
+
+	t.Run("cycle with branches", func(t *testing.T) {
+		wm, err := parseMapString(`A east=B
+B east=C south=E
+C south=D east=G
+D west=E
+G east=H
+K north=K1 south=K2 east=K3 west=K4
+P north=Q south=Q
+X east=Y
+`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"B", "C", "G", "K"}, wm.ArticulationPoints())
+		assert.Equal(t, []Road{
+			{"A", East, "B"}, {"C", East, "G"}, {"G", East, "H"},
+			{"K", South, "K2"}, {"K", East, "K3"},
+			{"K1", South, "K"}, {"K4", East, "K"},
+			{"X", East, "Y"},
+		}, wm.Bridges())
+		assert.Equal(t, []CriticalCity{
+			{Name: "K", Parts: 4, Disconnected: 3},
+			{Name: "C", Parts: 2, Disconnected: 2},
+			{Name: "B", Parts: 2, Disconnected: 1},
+			{Name: "G", Parts: 2, Disconnected: 1},
+		}, wm.CriticalCities())
+	})
+
+	t.Run("long line", func(t *testing.T) {
+		const n = 20000
+		wm := NewWorldMap()
+		for i := 0; i < n; i++ {
+			require.NoError(t, wm.AddCity(fmt.Sprintf("C%d", i)))
+			if i > 0 {
+				require.NoError(t, wm.Connect(fmt.Sprintf("C%d", i-1), East, fmt.Sprintf("C%d", i)))
+			}
+		}
+		assert.Len(t, wm.ArticulationPoints(), n-2)
+		assert.Len(t, wm.Bridges(), n-1)
+		top := wm.CriticalCities()[0]
+		assert.Equal(t, CriticalCity{Name: fmt.Sprintf("C%d", n/2-1), Parts: 2, Disconnected: n/2 - 1}, top)
+	})
+}
@@ -0,0 +1,226 @@
+package invasion
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// csv headers
+var (
+	csvEdgesHeader = []string{"from", "direction", "to"}
+	csvNodesHeader = "name"
+)
+
+// DecodeCSV decodes a world map from an edge list CSV and an optional node
+// table CSV (nodes can be nil).
+//
+// The edge list has the header `from,direction,to` and one row per link,
+// for example `C1,south,C4`. The node table has the header `name` followed
+// by one column per attribute, and one row per city. Empty cells mean that
+// the city does not have that attribute. Cities without links can only be
+// defined in the node table.
+//
+// The map is validated like in ParseWorldMap, so the same options are
+// accepted, and the links of a city are handled as a single definition.
+func DecodeCSV(edges io.Reader, nodes io.Reader, opts ...ParseOption) (*WorldMap, error) {
+	var order []string
+	cities := make(map[string]*rawCityData)
+	getOrCreate := func(name string) *rawCityData {
+		d, ok := cities[name]
+		if !ok {
+			d = &rawCityData{name: name}
+			cities[name] = d
+			order = append(order, name)
+		}
+		return d
+	}
+
+	if nodes != nil {
+		r := csv.NewReader(nodes)
+		header, err := r.Read()
+		if err != nil {
+			return nil, csvError(r, err)
+		}
+		if header[0] != csvNodesHeader {
+			return nil, csvError(r, fmt.Errorf("the node table header should start with %q", csvNodesHeader))
+		}
+		for _, key := range header[1:] {
+			if err := validateAttrKey(key); err != nil {
+				return nil, csvError(r, err)
+			}
+		}
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, csvError(r, err)
+			}
+			if err := validateCityName(rec[0]); err != nil {
+				return nil, csvError(r, err)
+			}
+			if _, exist := cities[rec[0]]; exist {
+				return nil, csvError(r, fmt.Errorf("city %s is in more than one row", rec[0]))
+			}
+			d := getOrCreate(rec[0])
+			for i, v := range rec[1:] {
+				if v == "" {
+					continue
+				}
+				if err := validateAttrValue(header[i+1], v); err != nil {
+					return nil, csvError(r, err)
+				}
+				if d.attrs == nil {
+					d.attrs = make(map[string]string)
+				}
+				d.attrs[header[i+1]] = v
+			}
+		}
+	}
+
+	r := csv.NewReader(edges)
+	r.FieldsPerRecord = len(csvEdgesHeader)
+	header, err := r.Read()
+	if err != nil {
+		return nil, csvError(r, err)
+	}
+	for i, h := range csvEdgesHeader {
+		if header[i] != h {
+			return nil, csvError(r, errors.New("the edge list header should be from,direction,to"))
+		}
+	}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvError(r, err)
+		}
+		from, dirName, to := rec[0], rec[1], rec[2]
+		if err := validateCityName(from); err != nil {
+			return nil, csvError(r, err)
+		}
+		if err := validateCityName(to); err != nil {
+			return nil, csvError(r, err)
+		}
+		dir, err := ParseDirection(dirName)
+		if err != nil {
+			return nil, csvError(r, err)
+		}
+		d := getOrCreate(from)
+		if d.dirs[dir] != "" && d.dirs[dir] != to {
+			return nil, csvError(r, fmt.Errorf("multiple %s directions for city %s", dir, from))
+		}
+		d.dirs[dir] = to
+	}
+
+	b := newMapBuilder(opts)
+	for _, name := range order {
+		if err := b.addCity(cities[name]); err != nil {
+			return nil, err
+		}
+	}
+	return b.build(), nil
+}
+
+// EncodeEdgeListCSV writes the links of the world map as an edge list CSV
+// with the header `from,direction,to`. Both links of each road are written,
+// sorted by city name and direction. The edge list is lossy: the header
+// comments, the metadata, the attributes and the cities without roads are
+// not written (use EncodeNodeCSV for the last two).
+func EncodeEdgeListCSV(w io.Writer, m *WorldMap) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvEdgesHeader)
+	for _, c := range m.sortedCities() {
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			cw.Write([]string{c.name, Direction(i).String(), sc.name})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// EncodeNodeCSV writes the cities of the world map as a node table CSV. The
+// header is `name` followed by every attribute used in the map, sorted.
+func EncodeNodeCSV(w io.Writer, m *WorldMap) error {
+	keySet := make(map[string]string)
+	for _, c := range m.cities {
+		for k := range c.attrs {
+			keySet[k] = ""
+		}
+	}
+	keys := sortedAttrKeys(keySet)
+
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{csvNodesHeader}, keys...))
+	for _, c := range m.sortedCities() {
+		rec := make([]string, 0, len(keys)+1)
+		rec = append(rec, c.name)
+		for _, k := range keys {
+			rec = append(rec, c.attrs[k])
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// csvError wraps an error found while decoding a CSV map, including the
+// line where it was found.
+func csvError(r *csv.Reader, err error) error {
+	if errors.Is(err, io.EOF) {
+		return errors.New("Cannot decode the CSV map: missing header")
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("Cannot decode the CSV map: %w", err)
+	}
+	line, _ := r.FieldPos(0)
+	return fmt.Errorf("Cannot decode the CSV map: line %d: %v", line, err)
+}
+
+// csvLostData describes the data of the map that EncodeEdgeListCSV cannot
+// write.
+func csvLostData(m *WorldMap) []string {
+	var lost []string
+	if n := len(m.header); n > 0 {
+		lost = append(lost, countOf(n, "header comment", "header comments"))
+	}
+	if n := len(m.meta); n > 0 {
+		lost = append(lost, countOf(n, "metadata entry", "metadata entries"))
+	}
+	attrs, isolated := 0, 0
+	for _, c := range m.cities {
+		attrs += len(c.attrs)
+		if len(c.surroundingCities()) == 0 {
+			isolated++
+		}
+	}
+	if attrs > 0 {
+		lost = append(lost, countOf(attrs, "attribute", "attributes"))
+	}
+	if isolated > 0 {
+		lost = append(lost, countOf(isolated, "city without roads", "cities without roads"))
+	}
+	return lost
+}
+
+// countOf returns n followed by the singular or the plural form of what is
+// counted.
+func countOf(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
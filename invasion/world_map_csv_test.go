@@ -0,0 +1,119 @@
+package invasion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_DecodeCSV(t *testing.T) {
+
+	t.Run("edges and nodes", func(t *testing.T) {
+		wm, err := DecodeCSV(
+			strings.NewReader("from,direction,to\nC1,east,C2\nC1,south,C3\nC2,west,C1\n"),
+			strings.NewReader("name,defense,terrain\nC1,3,\nC2,,mountain\nC4,,\n"),
+		)
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, wm))
+		assert.Equal(t, `C1 south=C3 east=C2 @defense=3
+C2 west=C1 @terrain=mountain
+C3 north=C1
+C4
+`, buf.String())
+	})
+
+	t.Run("only edges", func(t *testing.T) {
+		wm, err := DecodeCSV(strings.NewReader("from,direction,to\nC1,east,C2\n"), nil)
+		require.NoError(t, err)
+		assert.Len(t, wm.cities, 2)
+		assert.Equal(t, "C2 west=C1", wm.cities["C2"].String())
+	})
+
+	t.Run("inconsistent map", func(t *testing.T) {
+		wm, err := DecodeCSV(strings.NewReader("from,direction,to\nC1,east,C2\nC3,east,C2\n"), nil)
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+
+		wm, err = DecodeCSV(strings.NewReader("from,direction,to\nC1,east,C2\nC2,west,C3\n"), nil)
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+	})
+
+	t.Run("invalid edge list", func(t *testing.T) {
+		for _, tc := range []struct{ data, err string }{
+			{"", "Cannot decode the CSV map: missing header"},
+			{"a,b,c\n", "Cannot decode the CSV map: line 1: the edge list header should be from,direction,to"},
+			{"from,direction,to\nC1,east\n", "Cannot decode the CSV map: record on line 2: wrong number of fields"},
+			{"from,direction,to\nC1,up,C2\n", "Cannot decode the CSV map: line 2: up is not a valid direction"},
+			{"from,direction,to\nC1,east,New York\n", `Cannot decode the CSV map: line 2: "New York" is not a valid city name`},
+			{"from,direction,to\n,east,C2\n", "Cannot decode the CSV map: line 2: empty city name"},
+			{"from,direction,to\nC1,east,C2\nC1,east,C3\n", "Cannot decode the CSV map: line 3: multiple east directions for city C1"},
+		} {
+			wm, err := DecodeCSV(strings.NewReader(tc.data), nil)
+			assert.Nil(t, wm)
+			assert.EqualError(t, err, tc.err)
+		}
+	})
+
+	t.Run("invalid node table", func(t *testing.T) {
+		edges := "from,direction,to\n"
+		for _, tc := range []struct{ data, err string }{
+			{"", "Cannot decode the CSV map: missing header"},
+			{"city,defense\n", `Cannot decode the CSV map: line 1: the node table header should start with "name"`},
+			{"name,def ense\n", "Cannot decode the CSV map: line 1: @def ense is not a valid attribute name"},
+			{"name,defense\nC1,3\nC1,4\n", "Cannot decode the CSV map: line 3: city C1 is in more than one row"},
+			{"name,defense\nC1,3 4\n", `Cannot decode the CSV map: line 2: attribute @defense has an invalid value "3 4"`},
+		} {
+			wm, err := DecodeCSV(strings.NewReader(edges), strings.NewReader(tc.data))
+			assert.Nil(t, wm)
+			assert.EqualError(t, err, tc.err)
+		}
+	})
+}
+
+func TestWorldMap_EncodeCSV(t *testing.T) {
+	wm, err := parseMapString("C1 south=C3 east=C2 @defense=3\nC2 @terrain=mountain\nC4\n")
+	require.NoError(t, err)
+
+	edges := &bytes.Buffer{}
+	require.NoError(t, EncodeEdgeListCSV(edges, wm))
+	assert.Equal(t, `from,direction,to
+C1,south,C3
+C1,east,C2
+C2,west,C1
+C3,north,C1
+`, edges.String())
+
+	nodes := &bytes.Buffer{}
+	require.NoError(t, EncodeNodeCSV(nodes, wm))
+	assert.Equal(t, `name,defense,terrain
+C1,3,
+C2,,mountain
+C3,,
+C4,,
+`, nodes.String())
+
+	t.Run("round trip", func(t *testing.T) {
+		wm2, err := DecodeCSV(edges, nodes)
+		require.NoError(t, err)
+		assert.Equal(t, wm.cities, wm2.cities)
+	})
+
+	t.Run("lost data", func(t *testing.T) {
+		c, ok := LookupCodec("csv")
+		require.True(t, ok)
+		assert.Equal(t, []string{"2 attributes", "1 city without roads"}, c.Lost(wm))
+
+		wm, err := parseMapString("# a map\n# @author=me\nC1 east=C2\n")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1 header comment", "1 metadata entry"}, c.Lost(wm))
+
+		wm, err = parseMapString("C1 east=C2\n")
+		require.NoError(t, err)
+		assert.Empty(t, c.Lost(wm))
+	})
+}
@@ -0,0 +1,276 @@
+package invasion
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MapDiff holds the differences between two world maps.
+type MapDiff struct {
+	// RemovedCities has the cities that are only in the first map, sorted.
+	RemovedCities []string
+	// AddedCities has the cities that are only in the second map, sorted.
+	AddedCities []string
+	// RemovedRoads has the roads between cities of both maps that are only
+	// in the first map, sorted like in WorldMap.Roads. The roads of the
+	// removed cities are not included.
+	RemovedRoads []Road
+	// AddedRoads has the roads between cities of both maps that are only in
+	// the second map, sorted like in WorldMap.Roads. The roads of the added
+	// cities are not included.
+	AddedRoads []Road
+	// ChangedLinks has the links of the cities of both maps that lead to a
+	// different city in each map. Their roads are not included in
+	// RemovedRoads and AddedRoads.
+	ChangedLinks []LinkChange
+}
+
+// LinkChange represents a city link that leads to different cities in two
+// maps.
+type LinkChange struct {
+	City string
+	Dir  Direction
+	Old  string
+	New  string
+}
+
+// String implements fmt.Stringer.
+//
+func (c LinkChange) String() string {
+	return fmt.Sprintf("%s %s=%s -> %s=%s", c.City, c.Dir, c.Old, c.Dir, c.New)
+}
+
+// Empty reports whether both maps have the same cities and roads.
+//
+func (d *MapDiff) Empty() bool {
+	return len(d.RemovedCities) == 0 && len(d.AddedCities) == 0 &&
+		len(d.RemovedRoads) == 0 && len(d.AddedRoads) == 0 && len(d.ChangedLinks) == 0
+}
+
+// DiffMaps compares two world maps. City attributes and map metadata are
+// not compared.
+func DiffMaps(before, after *WorldMap) *MapDiff {
+	d := &MapDiff{}
+	for _, c := range before.sortedCities() {
+		if !after.HasCity(c.name) {
+			d.RemovedCities = append(d.RemovedCities, c.name)
+		}
+	}
+	for _, c := range after.sortedCities() {
+		if !before.HasCity(c.name) {
+			d.AddedCities = append(d.AddedCities, c.name)
+		}
+	}
+
+	// links of the same city that lead to a different city in each map
+	changed := make(map[string]bool)
+	for _, c := range before.sortedCities() {
+		ac, ok := after.cities[c.name]
+		if !ok {
+			continue
+		}
+		for i := range c.dirs {
+			old, cur := c.dirs[i], ac.dirs[i]
+			if old == nil || cur == nil || old.name == cur.name {
+				continue
+			}
+			dir := Direction(i)
+			d.ChangedLinks = append(d.ChangedLinks, LinkChange{City: c.name, Dir: dir, Old: old.name, New: cur.name})
+			changed[c.name+" "+dir.String()] = true
+		}
+	}
+	explained := func(r Road) bool {
+		return changed[r.From+" "+r.Dir.String()] || changed[r.To+" "+r.Dir.Opposite().String()]
+	}
+
+	d.RemovedRoads = roadsOnlyIn(before, after, explained)
+	d.AddedRoads = roadsOnlyIn(after, before, explained)
+	return d
+}
+
+// ReadResultMap reads the result map printed by Start (the lines after
+// "Result map:"), so it can be compared with the invaded map.
+func ReadResultMap(r io.Reader, opts ...ParseOption) (*WorldMap, error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") == resultMapHeader {
+			break
+		}
+		if err == io.EOF {
+			return nil, errors.New("Cannot parse the result: missing result map")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Cannot parse the result: %w", err)
+		}
+	}
+
+	// an empty result map is printed as a single line
+	head, err := br.Peek(len(noCitiesLine))
+	if err == nil && string(head) == noCitiesLine {
+		return NewWorldMap(), nil
+	}
+	return ReadWorldMap(br, opts...)
+}
+
+// IsResult reports whether data is a result printed by Start, i.e. it has
+// the "Result map:" line read by ReadResultMap.
+func IsResult(data []byte) bool {
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		if string(bytes.TrimRight(line, "\r")) == resultMapHeader {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteDiff writes the differences between two maps as text, one section
+// per kind of change. Empty sections are not written.
+func WriteDiff(w io.Writer, d *MapDiff) error {
+	bw := bufio.NewWriter(w)
+	if d.Empty() {
+		bw.WriteString("The maps are equal.\n")
+		return bw.Flush()
+	}
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(bw, "%s (%d):\n", title, len(lines))
+		for _, l := range lines {
+			fmt.Fprintf(bw, "  %s\n", l)
+		}
+	}
+	section("Removed cities", d.RemovedCities)
+	section("Added cities", d.AddedCities)
+	section("Removed roads", roadStrings(d.RemovedRoads))
+	section("Added roads", roadStrings(d.AddedRoads))
+	changes := make([]string, 0, len(d.ChangedLinks))
+	for _, c := range d.ChangedLinks {
+		changes = append(changes, c.String())
+	}
+	section("Changed links", changes)
+	return bw.Flush()
+}
+
+// EncodeDiffDOT exports the differences between two maps as a Graphviz DOT
+// digraph, like EncodeDOT. All the cities and roads of both maps are
+// drawn: the removed cities and roads in red, the added ones in green and
+// the rest as usual. Changed links are drawn as a removed road and an added
+// one. Cities are pinned to the layout of the first map, or the layout of
+// the second one if they are not in the first.
+func EncodeDiffDOT(w io.Writer, before, after *WorldMap) error {
+	d := DiffMaps(before, after)
+	removedCities := stringSet(d.RemovedCities)
+	addedCities := stringSet(d.AddedCities)
+	beforeCoords := before.Layout().Coords
+	afterCoords := after.Layout().Coords
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph diff {\n")
+	bw.WriteString("\tlayout=neato;\n")
+	bw.WriteString("\tnode [shape=box];\n")
+
+	names := append(before.Cities(), d.AddedCities...)
+	sort.Strings(names)
+	for _, name := range names {
+		attrs := make([]string, 0, 4)
+		if _, ok := removedCities[name]; ok {
+			attrs = append(attrs, "style=filled", "fillcolor=lightpink", "color=red")
+		} else if _, ok := addedCities[name]; ok {
+			attrs = append(attrs, "style=filled", "fillcolor=palegreen", "color=darkgreen")
+		}
+		p, ok := beforeCoords[name]
+		if !ok {
+			p, ok = afterCoords[name]
+		}
+		if ok {
+			attrs = append(attrs, fmt.Sprintf("pos=\"%d,%d!\"", p.X*dotScale, -p.Y*dotScale))
+		}
+		fmt.Fprintf(bw, "\t%s [%s];\n", dotQuote(name), strings.Join(attrs, ", "))
+	}
+
+	afterRoads := roadSet(after.Roads())
+	beforeRoads := roadSet(before.Roads())
+	writeRoad := func(r Road, style string) {
+		attrs := "label=" + dotQuote(r.Dir.String()) + style
+		fmt.Fprintf(bw, "\t%s -> %s [%s];\n", dotQuote(r.From), dotQuote(r.To), attrs)
+	}
+	for _, r := range before.Roads() {
+		if _, ok := afterRoads[r]; ok {
+			writeRoad(r, "")
+		} else {
+			writeRoad(r, ", color=red, fontcolor=red, style=dashed")
+		}
+	}
+	for _, r := range after.Roads() {
+		if _, ok := beforeRoads[r]; !ok {
+			writeRoad(r, ", color=darkgreen, fontcolor=darkgreen, penwidth=2")
+		}
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// roadsOnlyIn returns the roads of m between cities of both maps that are
+// not in other, skipping the roads explained by a changed link.
+func roadsOnlyIn(m, other *WorldMap, explained func(Road) bool) []Road {
+	otherRoads := roadSet(other.Roads())
+	var roads []Road
+	for _, r := range m.Roads() {
+		if !other.HasCity(r.From) || !other.HasCity(r.To) {
+			continue
+		}
+		if _, ok := otherRoads[r]; ok || explained(r) {
+			continue
+		}
+		roads = append(roads, r)
+	}
+	return roads
+}
+
+// roadSet returns a set with the given roads.
+//
+func roadSet(roads []Road) map[Road]struct{} {
+	set := make(map[Road]struct{}, len(roads))
+	for _, r := range roads {
+		set[r] = struct{}{}
+	}
+	return set
+}
+
+// stringSet returns a set with the given strings.
+//
+func stringSet(strs []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(strs))
+	for _, s := range strs {
+		set[s] = struct{}{}
+	}
+	return set
+}
+
+// roadStrings returns the roads as strings.
+//
+func roadStrings(roads []Road) []string {
+	strs := make([]string, 0, len(roads))
+	for _, r := range roads {
+		strs = append(strs, r.String())
+	}
+	return strs
+}
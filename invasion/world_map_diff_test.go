@@ -0,0 +1,130 @@
+package invasion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffMaps(t *testing.T) {
+
+	t.Run("equal maps", func(t *testing.T) {
+		d := DiffMaps(parseSmallMap(t), parseSmallMap(t))
+		assert.True(t, d.Empty())
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteDiff(buf, d))
+		assert.Equal(t, "The maps are equal.\n", buf.String())
+	})
+
+	t.Run("invasion result", func(t *testing.T) {
+		f := openTestdataFile(t, "result_case_1.txt")
+		defer f.Close()
+		result, err := ReadResultMap(f)
+		require.NoError(t, err)
+
+		d := DiffMaps(parseSmallMap(t), result)
+		assert.Equal(t, &MapDiff{RemovedCities: []string{"C2", "C5"}}, d)
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteDiff(buf, d))
+		assert.Equal(t, "Removed cities (2):\n  C2\n  C5\n", buf.String())
+	})
+
+	t.Run("roads and links", func(t *testing.T) {
+		before, err := parseMapString("A east=B south=D\nB south=E\nC west=B\nD east=E\n")
+		require.NoError(t, err)
+		after, err := parseMapString("A east=C south=D\nB south=E\nD east=E\nE east=F\n")
+		require.NoError(t, err)
+
+		d := DiffMaps(before, after)
+		assert.Equal(t, "A east=B -> east=C", d.ChangedLinks[0].String())
+		assert.Equal(t, &MapDiff{
+			AddedCities: []string{"F"},
+			ChangedLinks: []LinkChange{
+				{City: "A", Dir: East, Old: "B", New: "C"},
+				{City: "C", Dir: West, Old: "B", New: "A"},
+			},
+		}, d)
+
+		// B lost its roads to A and C
+		after, err = parseMapString("A south=D\nB south=E\nC\nD east=E\nC south=F\n")
+		require.NoError(t, err)
+		d = DiffMaps(before, after)
+		assert.Equal(t, &MapDiff{
+			AddedCities:  []string{"F"},
+			RemovedRoads: []Road{{"A", East, "B"}, {"B", East, "C"}},
+		}, d)
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteDiff(buf, d))
+		assert.Equal(t, `Added cities (1):
+  F
+Removed roads (2):
+  A east=B
+  B east=C
+`, buf.String())
+
+		d = DiffMaps(after, before)
+		assert.Equal(t, []Road{{"A", East, "B"}, {"B", East, "C"}}, d.AddedRoads)
+		assert.Equal(t, []string{"F"}, d.RemovedCities)
+	})
+}
+
+func TestReadResultMap(t *testing.T) {
+
+	t.Run("empty result map", func(t *testing.T) {
+		wm, err := ReadResultMap(strings.NewReader("C1 has been destroyed by alien 1 and alien 2!\n\nResult map:\nNo cities in the map.\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 0, wm.NumCities())
+	})
+
+	t.Run("written by Start", func(t *testing.T) {
+		prevFn := tStarterCityNameForAlienFn
+		defer func() { tStarterCityNameForAlienFn = prevFn }()
+		tStarterCityNameForAlienFn = func(int) string { return "C5" }
+
+		wm := parseSmallMap(t)
+		buf := &bytes.Buffer{}
+		Start(buf, wm, 1)
+		result, err := ReadResultMap(buf)
+		require.NoError(t, err)
+		assert.True(t, DiffMaps(wm, result).Empty())
+	})
+
+	t.Run("missing result map", func(t *testing.T) {
+		_, err := ReadResultMap(strings.NewReader("C1 north=C2\n"))
+		assert.EqualError(t, err, "Cannot parse the result: missing result map")
+	})
+}
+
+func TestIsResult(t *testing.T) {
+	assert.True(t, IsResult([]byte("C1 has been destroyed by alien 1 and alien 2!\n\nResult map:\nC2\n")))
+	assert.True(t, IsResult([]byte("Result map:\nNo cities in the map.\n")))
+	assert.True(t, IsResult([]byte("\r\nResult map:\r\nC2\r\n")))
+	assert.True(t, IsResult([]byte("C2\nResult map:")))
+	assert.False(t, IsResult([]byte("C1 north=C2\n# Result map:\n")))
+	assert.False(t, IsResult(nil))
+}
+
+func TestEncodeDiffDOT(t *testing.T) {
+	before, err := parseMapString("A east=B\nB east=C\n")
+	require.NoError(t, err)
+	after, err := parseMapString("A east=B\nB south=D\n")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, EncodeDiffDOT(buf, before, after))
+	assert.Equal(t, `digraph diff {
+	layout=neato;
+	node [shape=box];
+	"A" [pos="0,0!"];
+	"B" [pos="2,0!"];
+	"C" [style=filled, fillcolor=lightpink, color=red, pos="4,0!"];
+	"D" [style=filled, fillcolor=palegreen, color=darkgreen, pos="2,-2!"];
+	"A" -> "B" [label="east"];
+	"B" -> "C" [label="east", color=red, fontcolor=red, style=dashed];
+	"B" -> "D" [label="south", color=darkgreen, fontcolor=darkgreen, penwidth=2];
+}
+`, buf.String())
+}
@@ -0,0 +1,175 @@
+package invasion
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncodeOption configures how a world map is encoded by the graph
+// exporters (DOT, GraphML).
+type EncodeOption func(*encodeConfig)
+
+// WithReport overlays the result of an invasion on the exported map. The
+// map should be the same one passed to Start, so the destroyed cities and
+// their roads can be restored from the report.
+func WithReport(r *Report) EncodeOption {
+	return func(cfg *encodeConfig) {
+		cfg.report = r
+	}
+}
+
+// encodeConfig holds the options used by the graph exporters.
+type encodeConfig struct {
+	report *Report
+}
+
+// newEncodeConfig creates an encode config applying the given options.
+//
+func newEncodeConfig(opts []EncodeOption) encodeConfig {
+	cfg := encodeConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// dotScale is the distance in inches between two adjacent cities.
+const dotScale = 2
+
+// EncodeDOT exports the world map as a Graphviz DOT digraph. Each road is
+// written once, from the city that has the other one at its south or east,
+// and labelled with that direction. Cities are pinned to the coordinates
+// inferred by Layout, so the graph should be rendered with neato (the
+// default layout engine of the exported graph).
+//
+// With WithReport, the destroyed cities are greyed out and show the aliens
+// that destroyed them, and the cities with surviving or trapped aliens are
+// highlighted.
+func EncodeDOT(w io.Writer, m *WorldMap, opts ...EncodeOption) error {
+	cfg := newEncodeConfig(opts)
+	full, destroyed := restoreDestroyedCities(m, cfg.report)
+	coords := full.Layout().Coords
+
+	survivors := make(map[string]*alienSet)
+	trapped := make(map[string]*alienSet)
+	if cfg.report != nil {
+		for _, a := range cfg.report.Aliens {
+			if a.Trapped {
+				addAlienToCity(trapped, a.City, a.Num)
+			} else {
+				addAlienToCity(survivors, a.City, a.Num)
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph world {\n")
+	bw.WriteString("\tlayout=neato;\n")
+	bw.WriteString("\tnode [shape=box];\n")
+
+	cities := full.sortedCities()
+	for _, c := range cities {
+		attrs := make([]string, 0, 6)
+		label := []string{c.name}
+		if dc, ok := destroyed[c.name]; ok {
+			label = append(label,
+				fmt.Sprintf("destroyed by %s", newAlienSetOf(dc.Aliens)),
+				fmt.Sprintf("(iteration %d)", dc.Iteration))
+			attrs = append(attrs, "style=filled", "fillcolor=lightgrey", "color=grey", "fontcolor=grey40")
+		} else if aSet, ok := trapped[c.name]; ok {
+			label = append(label, fmt.Sprintf("trapped: %s", aSet))
+			attrs = append(attrs, "style=filled", "fillcolor=orange")
+		} else if aSet, ok := survivors[c.name]; ok {
+			label = append(label, fmt.Sprintf("aliens: %s", aSet))
+			attrs = append(attrs, "style=filled", "fillcolor=lightblue")
+		}
+		if len(label) > 1 {
+			attrs = append([]string{"label=" + dotQuote(strings.Join(label, "\n"))}, attrs...)
+		}
+		if p, ok := coords[c.name]; ok {
+			attrs = append(attrs, fmt.Sprintf("pos=\"%d,%d!\"", p.X*dotScale, -p.Y*dotScale))
+		}
+		fmt.Fprintf(bw, "\t%s [%s];\n", dotQuote(c.name), strings.Join(attrs, ", "))
+	}
+
+	for _, c := range cities {
+		for _, dir := range []Direction{South, East} {
+			sc := c.dirs[dir]
+			if sc == nil {
+				continue
+			}
+			attrs := "label=" + dotQuote(dir.String())
+			_, d1 := destroyed[c.name]
+			_, d2 := destroyed[sc.name]
+			if d1 || d2 {
+				attrs += ", style=dashed, color=grey"
+			}
+			fmt.Fprintf(bw, "\t%s -> %s [%s];\n", dotQuote(c.name), dotQuote(sc.name), attrs)
+		}
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// restoreDestroyedCities returns a copy of the map with the cities destroyed
+// in the report restored, together with their roads. The second value has
+// the destroyed cities by name. If the report is nil, this returns the same map.
+func restoreDestroyedCities(m *WorldMap, r *Report) (*WorldMap, map[string]DestroyedCity) {
+	destroyed := make(map[string]DestroyedCity)
+	if r == nil {
+		return m, destroyed
+	}
+	full := &WorldMap{cities: make(map[string]*city, len(m.cities)+len(r.Destroyed))}
+	link := func(name string, links [4]string) {
+		c := full.getOrCreateCity(name)
+		for i, n := range links {
+			if n == "" {
+				continue
+			}
+			sc := full.getOrCreateCity(n)
+			c.dirs[i] = sc
+			sc.dirs[Direction(i).Opposite()] = c
+		}
+	}
+	for _, c := range m.cities {
+		var links [4]string
+		for i, sc := range c.dirs {
+			if sc != nil {
+				links[i] = sc.name
+			}
+		}
+		link(c.name, links)
+		full.cities[c.name].attrs = c.attrs
+	}
+	for _, dc := range r.Destroyed {
+		destroyed[dc.Name] = dc
+		link(dc.Name, dc.links)
+	}
+	return full, destroyed
+}
+
+// newAlienSetOf creates an alien set with the given aliens.
+//
+func newAlienSetOf(aliens []int) *alienSet {
+	s := newAlienSet()
+	for _, a := range aliens {
+		s.add(a)
+	}
+	return s
+}
+
+// dotQuote returns the string as a DOT quoted identifier. New lines are
+// written as DOT line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
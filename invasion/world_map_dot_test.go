@@ -0,0 +1,97 @@
+package invasion
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_EncodeDOT(t *testing.T) {
+
+	t.Run("map without report", func(t *testing.T) {
+		wm, err := parseMapString("C1 south=C3 east=C2\nC2 south=C4\nC3 east=C4\n")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeDOT(buf, wm))
+		assert.Equal(t, `digraph world {
+	layout=neato;
+	node [shape=box];
+	"C1" [pos="0,0!"];
+	"C2" [pos="2,0!"];
+	"C3" [pos="0,-2!"];
+	"C4" [pos="2,-2!"];
+	"C1" -> "C3" [label="south"];
+	"C1" -> "C2" [label="east"];
+	"C2" -> "C4" [label="south"];
+	"C3" -> "C4" [label="east"];
+}
+`, buf.String())
+	})
+
+	t.Run("cities that cannot be embedded are not pinned", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB south=C\nC west=D\nD north=E\n")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeDOT(buf, wm))
+		assert.Contains(t, buf.String(), "\t\"A\" [];\n")
+	})
+
+	t.Run("invasion overlay", func(t *testing.T) {
+		wm := parseSmallMap(t)
+
+		moves := map[int]*moveList{
+			0: newMoveList(wm, "C1", "C2"),
+			1: newMoveList(wm, "C3", "C2"),
+			2: newMoveList(wm, "C9", "C8", "C9"),
+		}
+		tStarterCityNameForAlienFn = func(alien int) string {
+			return moves[alien].poll().name
+		}
+		tAlienMoveNextCityFn = func(alien int, curCity *city, possibleCities []*city) *city {
+			ret := moves[alien].poll()
+			require.Contains(t, possibleCities, ret, fmt.Sprintf("alien %d can't go to %s from %s", alien, ret.name, curCity.name))
+			return ret
+		}
+
+		r := Start(&bytes.Buffer{}, wm, len(moves))
+		require.NotNil(t, r)
+		r.Aliens = append(r.Aliens, AlienStatus{Num: 3, City: "C7", Trapped: true})
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeDOT(buf, wm, WithReport(r)))
+		assert.Equal(t, `digraph world {
+	layout=neato;
+	node [shape=box];
+	"C1" [pos="0,0!"];
+	"C2" [label="C2\ndestroyed by alien 0 and alien 1\n(iteration 1)", style=filled, fillcolor=lightgrey, color=grey, fontcolor=grey40, pos="2,0!"];
+	"C3" [pos="4,0!"];
+	"C4" [pos="0,-2!"];
+	"C5" [pos="2,-2!"];
+	"C6" [pos="4,-2!"];
+	"C7" [label="C7\ntrapped: alien 3", style=filled, fillcolor=orange, pos="0,-4!"];
+	"C8" [pos="2,-4!"];
+	"C9" [label="C9\naliens: alien 2", style=filled, fillcolor=lightblue, pos="4,-4!"];
+	"C1" -> "C4" [label="south"];
+	"C1" -> "C2" [label="east", style=dashed, color=grey];
+	"C2" -> "C5" [label="south", style=dashed, color=grey];
+	"C2" -> "C3" [label="east", style=dashed, color=grey];
+	"C3" -> "C6" [label="south"];
+	"C4" -> "C7" [label="south"];
+	"C4" -> "C5" [label="east"];
+	"C5" -> "C8" [label="south"];
+	"C5" -> "C6" [label="east"];
+	"C6" -> "C9" [label="south"];
+	"C7" -> "C8" [label="east"];
+	"C8" -> "C9" [label="east"];
+}
+`, buf.String())
+	})
+}
+
+func TestWorldMap_dotQuote(t *testing.T) {
+	assert.Equal(t, `"C1"`, dotQuote("C1"))
+	assert.Equal(t, `"a \"b\"\nc\\d"`, dotQuote("a \"b\"\nc\\d"))
+}
@@ -0,0 +1,95 @@
+package invasion
+
+import (
+	"fmt"
+)
+
+// Subgraph returns a new map with the given cities. The roads to cities
+// that are not in the list are dropped. The attributes of the cities, the
+// map metadata and the header comments are kept.
+func (m *WorldMap) Subgraph(names []string) (*WorldMap, error) {
+	keep := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if !m.HasCity(name) {
+			return nil, fmt.Errorf("city %s does not exist", name)
+		}
+		keep[name] = struct{}{}
+	}
+	return m.subgraph(keep), nil
+}
+
+// Neighbourhood returns a new map with the cities that are at most k roads
+// away from the center city, extracted like in Subgraph.
+func (m *WorldMap) Neighbourhood(center string, k int) (*WorldMap, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("the number of roads cannot be negative")
+	}
+	dists := m.Distances(center)
+	if dists == nil {
+		return nil, fmt.Errorf("city %s does not exist", center)
+	}
+	keep := make(map[string]struct{}, len(dists))
+	for name, d := range dists {
+		if d <= k {
+			keep[name] = struct{}{}
+		}
+	}
+	return m.subgraph(keep), nil
+}
+
+// Region returns a new map with the cities placed inside the rectangle of
+// the layout inferred by Layout (including its borders), extracted like in
+// Subgraph. The cities of components that cannot be embedded in the plane
+// have no coordinates, so they are never in the region.
+func (m *WorldMap) Region(box BoundingBox) *WorldMap {
+	keep := make(map[string]struct{})
+	for name, p := range m.Layout().Coords {
+		if p.X >= box.Min.X && p.X <= box.Max.X && p.Y >= box.Min.Y && p.Y <= box.Max.Y {
+			keep[name] = struct{}{}
+		}
+	}
+	return m.subgraph(keep)
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// subgraph returns a copy of the map with only the cities of the set.
+//
+func (m *WorldMap) subgraph(keep map[string]struct{}) *WorldMap {
+	sub := NewWorldMap()
+	sub.header = append(sub.header, m.header...)
+	if len(m.meta) > 0 {
+		sub.meta = make(map[string]string, len(m.meta))
+		for k, v := range m.meta {
+			sub.meta[k] = v
+		}
+	}
+	for _, name := range m.fileOrder {
+		if _, ok := keep[name]; ok {
+			sub.fileOrder = append(sub.fileOrder, name)
+		}
+	}
+	sub.order = m.order
+
+	for name := range keep {
+		c := m.cities[name]
+		sc := sub.getOrCreateCity(name)
+		for k, v := range c.attrs {
+			if sc.attrs == nil {
+				sc.attrs = make(map[string]string, len(c.attrs))
+			}
+			sc.attrs[k] = v
+		}
+		for i, n := range c.dirs {
+			if n == nil {
+				continue
+			}
+			if _, ok := keep[n.name]; ok {
+				sc.dirs[i] = sub.getOrCreateCity(n.name)
+			}
+		}
+	}
+	return sub
+}
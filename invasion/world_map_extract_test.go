@@ -0,0 +1,63 @@
+package invasion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_Extract(t *testing.T) {
+	writeMap := func(t *testing.T, m *WorldMap) string {
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, m))
+		return buf.String()
+	}
+
+	t.Run("named cities", func(t *testing.T) {
+		wm, err := parseMapString("# @author=someone\n# A small map\nC1 east=C2 south=C4 @defense=3\nC2 east=C3\nC4\n")
+		require.NoError(t, err)
+		sub, err := wm.Subgraph([]string{"C1", "C3", "C4"})
+		require.NoError(t, err)
+		assert.Equal(t, "# @author=someone\n# A small map\nC1 south=C4 @defense=3\nC3\nC4 north=C1\n", writeMap(t, sub))
+		assert.Equal(t, []string{"C1", "C4", "C3"}, sub.fileOrder)
+		require.NoError(t, sub.Validate())
+
+		// the original map is not changed
+		assert.Equal(t, 4, wm.NumCities())
+		assert.Equal(t, "C2", wm.Neighbours("C1")[East])
+
+		_, err = wm.Subgraph([]string{"C1", "C9"})
+		assert.EqualError(t, err, "city C9 does not exist")
+	})
+
+	t.Run("neighbourhood", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		sub, err := wm.Neighbourhood("C1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "C1 south=C4 east=C2\nC2 west=C1\nC4 north=C1\n", writeMap(t, sub))
+
+		sub, err = wm.Neighbourhood("C5", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "C5\n", writeMap(t, sub))
+
+		sub, err = wm.Neighbourhood("C5", 10)
+		require.NoError(t, err)
+		assert.Equal(t, writeMap(t, wm), writeMap(t, sub))
+
+		_, err = wm.Neighbourhood("C0", 1)
+		assert.EqualError(t, err, "city C0 does not exist")
+		_, err = wm.Neighbourhood("C1", -1)
+		assert.EqualError(t, err, "the number of roads cannot be negative")
+	})
+
+	t.Run("region", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		sub := wm.Region(BoundingBox{Min: Point{1, 1}, Max: Point{5, 5}})
+		assert.Equal(t, "C5 south=C8 east=C6\nC6 south=C9 west=C5\nC8 north=C5 east=C9\nC9 north=C6 west=C8\n", writeMap(t, sub))
+
+		sub = wm.Region(BoundingBox{Min: Point{3, 0}, Max: Point{5, 5}})
+		assert.Equal(t, 0, sub.NumCities())
+	})
+}
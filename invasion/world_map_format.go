@@ -0,0 +1,114 @@
+package invasion
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// FormatMap returns the canonical form of a text map:
+//
+// 	- the map metadata goes first, sorted by key, followed by the header comments
+// 	- each city is written in a single line, with the implied reverse links
+// 	  filled in (if A north=B, then B south=A)
+// 	- the directions are ordered north, south, east, west, followed by the
+// 	  city attributes sorted by name
+// 	- the cities are sorted by name in natural order (C2 before C10)
+//
+// The map is validated like in ParseWorldMap and the same options are
+// accepted. Blank lines are removed, but the comments found after the first
+// city are kept next to their cities: the comment lines go right before the
+// line of the city that follows them, and the comments at the end of a city
+// line stay at the end of its line. The comment lines after the last city
+// stay at the end of the map.
+func FormatMap(src []byte, opts ...ParseOption) ([]byte, error) {
+	m, err := ReadWorldMap(bytes.NewReader(src), opts...)
+	if err != nil {
+		return nil, err
+	}
+	comments, trailing := cityComments(src)
+	buf := &bytes.Buffer{}
+	buf.Grow(len(src))
+	bw := bufio.NewWriter(buf)
+	writeMapHeader(bw, m)
+	for _, c := range m.naturalSortedCities() {
+		cc, ok := comments[c.name]
+		if !ok {
+			// implied cities have no lines, so they have no comments
+			cc = &lineComments{}
+		}
+		for _, comment := range cc.before {
+			bw.WriteByte('#')
+			bw.WriteString(comment)
+			bw.WriteByte('\n')
+		}
+		bw.WriteString(c.String())
+		for _, comment := range cc.inline {
+			bw.WriteString(" #")
+			bw.WriteString(comment)
+		}
+		bw.WriteByte('\n')
+	}
+	for _, comment := range trailing {
+		bw.WriteByte('#')
+		bw.WriteString(comment)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatWorldMap writes the world map in the canonical form described in
+// FormatMap.
+func FormatWorldMap(w io.Writer, m *WorldMap) error {
+	return writeWorldMap(w, m, m.naturalSortedCities())
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// lineComments has the comments of a city found after the first city line.
+//
+type lineComments struct {
+	// before has the comment lines right before the city lines.
+	before []string
+	// inline has the comments at the end of the city lines.
+	inline []string
+}
+
+// cityComments returns the comments of a valid text map found after the
+// first city, by city name, and the comment lines after the last city.
+func cityComments(src []byte) (map[string]*lineComments, []string) {
+	comments := make(map[string]*lineComments)
+	var pending []string
+	seenCity := false
+	for _, line := range strings.Split(string(src), "\n") {
+		content, comment, hasComment := splitMapLineComment(line)
+		if strings.TrimSpace(content) == "" {
+			if hasComment && seenCity {
+				pending = append(pending, comment)
+			}
+			continue
+		}
+		data, err := decodeMapLine(content)
+		if err != nil {
+			continue
+		}
+		seenCity = true
+		cc, ok := comments[data.name]
+		if !ok {
+			cc = &lineComments{}
+			comments[data.name] = cc
+		}
+		cc.before = append(cc.before, pending...)
+		pending = nil
+		if hasComment {
+			cc.inline = append(cc.inline, comment)
+		}
+	}
+	return comments, pending
+}
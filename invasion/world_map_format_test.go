@@ -0,0 +1,70 @@
+package invasion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_FormatMap(t *testing.T) {
+
+	t.Run("canonical form", func(t *testing.T) {
+		src := "# Hand edited map\r\n" +
+			"# @author=someone\n" +
+			"\n" +
+			"C10 west=C9 @defense=3 north=C2\n" +
+			"C2   east=C3 # trailing comment\n" +
+			"C9\n" +
+			"# comment between cities\n" +
+			"C1 east=C2\n"
+		out, err := FormatMap([]byte(src))
+		require.NoError(t, err)
+		assert.Equal(t, "# @author=someone\n"+
+			"# Hand edited map\n"+
+			"# comment between cities\n"+
+			"C1 east=C2\n"+
+			"C2 south=C10 east=C3 west=C1 # trailing comment\n"+
+			"C3 west=C2\n"+
+			"C9 east=C10\n"+
+			"C10 north=C2 west=C9 @defense=3\n", string(out))
+
+		// formatting is idempotent
+		again, err := FormatMap(out)
+		require.NoError(t, err)
+		assert.Equal(t, out, again)
+	})
+
+	t.Run("comments are kept next to their cities", func(t *testing.T) {
+		src := "C3 west=C2 # first\n" +
+			"# about C2\n" +
+			"C2 east=C3\n" +
+			"C3 @defense=1 # second\n" +
+			"# end of the map\n"
+		out, err := FormatMap([]byte(src))
+		require.NoError(t, err)
+		assert.Equal(t, "# about C2\n"+
+			"C2 east=C3\n"+
+			"C3 west=C2 @defense=1 # first # second\n"+
+			"# end of the map\n", string(out))
+
+		again, err := FormatMap(out)
+		require.NoError(t, err)
+		assert.Equal(t, out, again)
+	})
+
+	t.Run("invalid map", func(t *testing.T) {
+		out, err := FormatMap([]byte("C1 north=C2\nC2 south=C3\n"))
+		assert.Nil(t, out)
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+	})
+
+	t.Run("small map is already formatted", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, FormatWorldMap(buf, parseSmallMap(t)))
+		// the test file has no final newline
+		assertFileResult(t, "small_map.txt", strings.TrimSuffix(buf.String(), "\n"))
+	})
+}
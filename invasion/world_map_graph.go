@@ -0,0 +1,219 @@
+package invasion
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Road represents a road between two cities. From has To at the given
+// direction, and To has From at the opposite one.
+type Road struct {
+	From string
+	Dir  Direction
+	To   string
+}
+
+// String implements fmt.Stringer.
+//
+func (r Road) String() string {
+	return fmt.Sprintf("%s %s=%s", r.From, r.Dir, r.To)
+}
+
+// NewWorldMap creates an empty world map.
+//
+func NewWorldMap() *WorldMap {
+	return &WorldMap{cities: make(map[string]*city)}
+}
+
+// NumCities returns the number of cities in the map.
+//
+func (m *WorldMap) NumCities() int {
+	return len(m.cities)
+}
+
+// HasCity reports whether the city exists in the map.
+//
+func (m *WorldMap) HasCity(name string) bool {
+	_, ok := m.cities[name]
+	return ok
+}
+
+// Cities returns the names of all the cities in the map, sorted.
+//
+func (m *WorldMap) Cities() []string {
+	names := make([]string, 0, len(m.cities))
+	for name := range m.cities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Neighbour returns the city at the given direction of the specified city
+// and whether there is one.
+func (m *WorldMap) Neighbour(name string, dir Direction) (string, bool) {
+	c, ok := m.cities[name]
+	if !ok || !dir.valid() || c.dirs[dir] == nil {
+		return "", false
+	}
+	return c.dirs[dir].name, true
+}
+
+// Neighbours returns the surrounding cities of the specified city by
+// direction. If the city does not exist, this returns nil.
+func (m *WorldMap) Neighbours(name string) map[Direction]string {
+	c, ok := m.cities[name]
+	if !ok {
+		return nil
+	}
+	ns := make(map[Direction]string, 4)
+	for i, sc := range c.dirs {
+		if sc != nil {
+			ns[Direction(i)] = sc.name
+		}
+	}
+	return ns
+}
+
+// Roads returns all the roads of the map. Each road is returned once, from
+// the city that has the other one at its south or east, sorted by city name
+// and direction.
+func (m *WorldMap) Roads() []Road {
+	var roads []Road
+	for _, c := range m.sortedCities() {
+		for _, dir := range []Direction{South, East} {
+			if sc := c.dirs[dir]; sc != nil {
+				roads = append(roads, Road{From: c.name, Dir: dir, To: sc.name})
+			}
+		}
+	}
+	return roads
+}
+
+// AddCity adds a new city without roads to the map. The name must be valid
+// in the map file format and cannot be already in the map.
+func (m *WorldMap) AddCity(name string) error {
+	if err := validateCityName(name); err != nil {
+		return err
+	}
+	if m.HasCity(name) {
+		return fmt.Errorf("city %s already exists", name)
+	}
+	if m.cities == nil {
+		m.cities = make(map[string]*city)
+	}
+	m.getOrCreateCity(name)
+	return nil
+}
+
+// RemoveCity removes a city and all its roads from the map.
+//
+func (m *WorldMap) RemoveCity(name string) error {
+	if m.destroyCity(name) == nil {
+		return fmt.Errorf("city %s does not exist", name)
+	}
+	return nil
+}
+
+// Connect adds a road from a city to another one at the given direction,
+// so `to` gets `from` at the opposite direction. Both cities must exist and
+// the directions of the road must be free in both of them. Connecting two
+// cities that are already connected does nothing.
+func (m *WorldMap) Connect(from string, dir Direction, to string) error {
+	if !dir.valid() {
+		return fmt.Errorf("%d is not a valid direction", dir)
+	}
+	fc, ok := m.cities[from]
+	if !ok {
+		return fmt.Errorf("city %s does not exist", from)
+	}
+	tc, ok := m.cities[to]
+	if !ok {
+		return fmt.Errorf("city %s does not exist", to)
+	}
+	if fc == tc {
+		return fmt.Errorf("city %s cannot be connected to itself", from)
+	}
+	if fc.dirs[dir] == tc && tc.dirs[dir.Opposite()] == fc {
+		return nil
+	}
+	if sc := fc.dirs[dir]; sc != nil {
+		return fmt.Errorf("city %s already has %s at its %s", from, sc.name, dir)
+	}
+	if sc := tc.dirs[dir.Opposite()]; sc != nil {
+		return fmt.Errorf("city %s already has %s at its %s", to, sc.name, dir.Opposite())
+	}
+	fc.dirs[dir] = tc
+	tc.dirs[dir.Opposite()] = fc
+	return nil
+}
+
+// Disconnect removes the road at the given direction of a city, together
+// with the opposite link of the other city.
+func (m *WorldMap) Disconnect(name string, dir Direction) error {
+	if !dir.valid() {
+		return fmt.Errorf("%d is not a valid direction", dir)
+	}
+	c, ok := m.cities[name]
+	if !ok {
+		return fmt.Errorf("city %s does not exist", name)
+	}
+	sc := c.dirs[dir]
+	if sc == nil {
+		return fmt.Errorf("city %s has no road to the %s", name, dir)
+	}
+	c.dirs[dir] = nil
+	if sc.dirs[dir.Opposite()] == c {
+		sc.dirs[dir.Opposite()] = nil
+	}
+	return nil
+}
+
+// Validate checks that the map is consistent: city names, attributes and
+// metadata can be written in the map file format, every road leads to a
+// city of the map, and every road has its opposite link. It returns the
+// first problem found, checking the cities sorted by name.
+func (m *WorldMap) Validate() error {
+	for _, k := range sortedAttrKeys(m.meta) {
+		if err := validateAttrKey(k); err != nil {
+			return fmt.Errorf("invalid map metadata: %v", err)
+		}
+		if err := validateAttrValue(k, m.meta[k]); err != nil {
+			return fmt.Errorf("invalid map metadata: %v", err)
+		}
+	}
+	for _, c := range m.sortedCities() {
+		if err := validateCityName(c.name); err != nil {
+			return fmt.Errorf("invalid map: %v", err)
+		}
+		for _, k := range sortedAttrKeys(c.attrs) {
+			if err := validateAttrKey(k); err != nil {
+				return fmt.Errorf("invalid city %s: %v", c.name, err)
+			}
+			if err := validateAttrValue(k, c.attrs[k]); err != nil {
+				return fmt.Errorf("invalid city %s: %v", c.name, err)
+			}
+		}
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			dir := Direction(i)
+			if sc == c {
+				return fmt.Errorf("invalid city %s: it is connected to itself at its %s", c.name, dir)
+			}
+			if m.cities[sc.name] != sc {
+				return fmt.Errorf("invalid city %s: %s at its %s is not in the map", c.name, sc.name, dir)
+			}
+			if back := sc.dirs[dir.Opposite()]; back != c {
+				if back == nil {
+					return fmt.Errorf("invalid city %s: %s is at its %s, but %s has no road to the %s",
+						c.name, sc.name, dir, sc.name, dir.Opposite())
+				}
+				return fmt.Errorf("invalid city %s: %s is at its %s, but %s has %s at its %s",
+					c.name, sc.name, dir, sc.name, back.name, dir.Opposite())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package invasion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_GraphAPI(t *testing.T) {
+
+	t.Run("build a map in code", func(t *testing.T) {
+		wm := NewWorldMap()
+		for _, name := range []string{"C1", "C2", "C3"} {
+			require.NoError(t, wm.AddCity(name))
+		}
+		require.NoError(t, wm.Connect("C1", East, "C2"))
+		require.NoError(t, wm.Connect("C3", North, "C1"))
+		// connecting the same cities again does nothing
+		require.NoError(t, wm.Connect("C2", West, "C1"))
+		require.NoError(t, wm.Validate())
+
+		assert.Equal(t, 3, wm.NumCities())
+		assert.Equal(t, []string{"C1", "C2", "C3"}, wm.Cities())
+		assert.Equal(t, map[Direction]string{South: "C3", East: "C2"}, wm.Neighbours("C1"))
+		assert.Equal(t, []Road{{"C1", South, "C3"}, {"C1", East, "C2"}}, wm.Roads())
+		assert.Equal(t, "C1 south=C3", wm.Roads()[0].String())
+
+		n, ok := wm.Neighbour("C2", West)
+		assert.True(t, ok)
+		assert.Equal(t, "C1", n)
+		_, ok = wm.Neighbour("C2", North)
+		assert.False(t, ok)
+		_, ok = wm.Neighbour("C9", North)
+		assert.False(t, ok)
+		assert.Nil(t, wm.Neighbours("C9"))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, wm))
+		assert.Equal(t, "C1 south=C3 east=C2\nC2 west=C1\nC3 north=C1\n", buf.String())
+	})
+
+	t.Run("zero value map", func(t *testing.T) {
+		wm := &WorldMap{}
+		require.NoError(t, wm.AddCity("A"))
+		assert.True(t, wm.HasCity("A"))
+	})
+
+	t.Run("add and remove cities", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		assert.EqualError(t, wm.AddCity("C1"), "city C1 already exists")
+		assert.EqualError(t, wm.AddCity("bad name"), `"bad name" is not a valid city name`)
+
+		require.NoError(t, wm.RemoveCity("C5"))
+		assert.False(t, wm.HasCity("C5"))
+		assert.Equal(t, map[Direction]string{North: "C1", South: "C7"}, wm.Neighbours("C4"))
+		assert.EqualError(t, wm.RemoveCity("C5"), "city C5 does not exist")
+		require.NoError(t, wm.Validate())
+	})
+
+	t.Run("connect errors", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		require.NoError(t, wm.AddCity("X"))
+		assert.EqualError(t, wm.Connect("C1", North, "C9X"), "city C9X does not exist")
+		assert.EqualError(t, wm.Connect("C9X", North, "C1"), "city C9X does not exist")
+		assert.EqualError(t, wm.Connect("X", North, "X"), "city X cannot be connected to itself")
+		assert.EqualError(t, wm.Connect("C1", East, "X"), "city C1 already has C2 at its east")
+		assert.EqualError(t, wm.Connect("X", East, "C2"), "city C2 already has C1 at its west")
+		assert.EqualError(t, wm.Connect("X", Direction(7), "C3"), "7 is not a valid direction")
+		require.NoError(t, wm.Connect("X", South, "C1"))
+		require.NoError(t, wm.Validate())
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		require.NoError(t, wm.Disconnect("C5", West))
+		assert.Equal(t, map[Direction]string{North: "C1", South: "C7"}, wm.Neighbours("C4"))
+		assert.EqualError(t, wm.Disconnect("C5", West), "city C5 has no road to the west")
+		assert.EqualError(t, wm.Disconnect("C0", West), "city C0 does not exist")
+		require.NoError(t, wm.Validate())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		wm.cities["C2"].dirs[South] = nil
+		assert.EqualError(t, wm.Validate(), "invalid city C5: C2 is at its north, but C2 has no road to the south")
+
+		wm = parseSmallMap(t)
+		wm.cities["C2"].dirs[South] = wm.cities["C6"]
+		assert.EqualError(t, wm.Validate(), "invalid city C2: C6 is at its south, but C6 has C3 at its north")
+
+		wm = parseSmallMap(t)
+		c1 := wm.destroyCity("C1")
+		wm.cities["C2"].dirs[West] = c1
+		assert.EqualError(t, wm.Validate(), "invalid city C2: C1 at its west is not in the map")
+
+		wm = parseSmallMap(t)
+		wm.cities["C7"].dirs[West] = wm.cities["C7"]
+		assert.EqualError(t, wm.Validate(), "invalid city C7: it is connected to itself at its west")
+
+		wm = parseSmallMap(t)
+		wm.cities["C7"].attrs = map[string]string{"pop": "a b"}
+		assert.EqualError(t, wm.Validate(), `invalid city C7: attribute @pop has an invalid value "a b"`)
+
+		wm = parseSmallMap(t)
+		wm.meta = map[string]string{"bad key": "1"}
+		assert.EqualError(t, wm.Validate(), "invalid map metadata: @bad key is not a valid attribute name")
+	})
+}
@@ -0,0 +1,150 @@
+package invasion
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// graphmlNamespace is the GraphML XML namespace.
+const graphmlNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// graphML represents the root element of a GraphML document.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+// graphMLKey declares a node or edge attribute.
+type graphMLKey struct {
+	ID      string `xml:"id,attr"`
+	For     string `xml:"for,attr"`
+	Name    string `xml:"attr.name,attr"`
+	Type    string `xml:"attr.type,attr"`
+	Default string `xml:"default,omitempty"`
+}
+
+// graphMLGraph represents a GraphML graph.
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+// graphMLNode represents a GraphML node.
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+// graphMLEdge represents a GraphML edge.
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// graphMLData holds the value of an attribute.
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// EncodeGraphML exports the world map as a directed GraphML graph, with one
+// edge per link (so each road has two edges) and its direction as attribute.
+// Nodes have the city name, the coordinates inferred by Layout (if the city
+// could be embedded) and the city attributes, which are named with the '@'
+// prefix (e.g. `@defense`).
+//
+// With WithReport, the destroyed cities are restored and the nodes have the
+// `destroyed`, `destroyed_iteration` and `destroyed_by` attributes.
+func EncodeGraphML(w io.Writer, m *WorldMap, opts ...EncodeOption) error {
+	cfg := newEncodeConfig(opts)
+	full, destroyed := restoreDestroyedCities(m, cfg.report)
+	coords := full.Layout().Coords
+
+	doc := graphML{
+		Xmlns: graphmlNamespace,
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "x", For: "node", Name: "x", Type: "int"},
+			{ID: "y", For: "node", Name: "y", Type: "int"},
+		},
+		Graph: graphMLGraph{ID: "world", EdgeDefault: "directed"},
+	}
+	if cfg.report != nil {
+		doc.Keys = append(doc.Keys,
+			graphMLKey{ID: "destroyed", For: "node", Name: "destroyed", Type: "boolean", Default: "false"},
+			graphMLKey{ID: "destroyed_iteration", For: "node", Name: "destroyed_iteration", Type: "int"},
+			graphMLKey{ID: "destroyed_by", For: "node", Name: "destroyed_by", Type: "string"},
+		)
+	}
+	keySet := make(map[string]string)
+	for _, c := range full.cities {
+		for k := range c.attrs {
+			keySet[k] = ""
+		}
+	}
+	attrKeys := sortedAttrKeys(keySet)
+	for _, k := range attrKeys {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: graphMLAttrKeyID(k), For: "node", Name: attrPrefix + k, Type: "string"})
+	}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "direction", For: "edge", Name: "direction", Type: "string"})
+
+	for _, c := range full.sortedCities() {
+		n := graphMLNode{ID: c.name, Data: []graphMLData{{Key: "name", Value: c.name}}}
+		if p, ok := coords[c.name]; ok {
+			n.Data = append(n.Data,
+				graphMLData{Key: "x", Value: strconv.Itoa(p.X)},
+				graphMLData{Key: "y", Value: strconv.Itoa(p.Y)})
+		}
+		if dc, ok := destroyed[c.name]; ok {
+			aliens := make([]string, 0, len(dc.Aliens))
+			for _, a := range dc.Aliens {
+				aliens = append(aliens, strconv.Itoa(a))
+			}
+			n.Data = append(n.Data,
+				graphMLData{Key: "destroyed", Value: "true"},
+				graphMLData{Key: "destroyed_iteration", Value: strconv.Itoa(dc.Iteration)},
+				graphMLData{Key: "destroyed_by", Value: strings.Join(aliens, " ")})
+		}
+		for _, k := range attrKeys {
+			if v, ok := c.attrs[k]; ok {
+				n.Data = append(n.Data, graphMLData{Key: graphMLAttrKeyID(k), Value: v})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: c.name,
+				Target: sc.name,
+				Data:   []graphMLData{{Key: "direction", Value: Direction(i).String()}},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// graphMLAttrKeyID returns the GraphML key id of a city attribute.
+//
+func graphMLAttrKeyID(key string) string {
+	return "attr." + key
+}
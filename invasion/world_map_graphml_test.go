@@ -0,0 +1,83 @@
+package invasion
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_EncodeGraphML(t *testing.T) {
+
+	t.Run("map without report", func(t *testing.T) {
+		wm, err := parseMapString("C1 east=C2 @defense=3\nC2\n")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeGraphML(buf, wm))
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="name" for="node" attr.name="name" attr.type="string"></key>
+  <key id="x" for="node" attr.name="x" attr.type="int"></key>
+  <key id="y" for="node" attr.name="y" attr.type="int"></key>
+  <key id="attr.defense" for="node" attr.name="@defense" attr.type="string"></key>
+  <key id="direction" for="edge" attr.name="direction" attr.type="string"></key>
+  <graph id="world" edgedefault="directed">
+    <node id="C1">
+      <data key="name">C1</data>
+      <data key="x">0</data>
+      <data key="y">0</data>
+      <data key="attr.defense">3</data>
+    </node>
+    <node id="C2">
+      <data key="name">C2</data>
+      <data key="x">1</data>
+      <data key="y">0</data>
+    </node>
+    <edge source="C1" target="C2">
+      <data key="direction">east</data>
+    </edge>
+    <edge source="C2" target="C1">
+      <data key="direction">west</data>
+    </edge>
+  </graph>
+</graphml>
+`, buf.String())
+	})
+
+	t.Run("invasion overlay", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		c5 := wm.destroyCity("C5")
+		r := &Report{
+			Destroyed: []DestroyedCity{
+				{Name: "C5", Iteration: 3, Aliens: []int{1, 4}, links: [4]string{
+					North: c5.dirs[North].name, South: c5.dirs[South].name,
+					East: c5.dirs[East].name, West: c5.dirs[West].name,
+				}},
+			},
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeGraphML(buf, wm, WithReport(r)))
+
+		var doc graphML
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, graphmlNamespace, doc.Xmlns)
+		require.Len(t, doc.Graph.Nodes, 9)
+		assert.Len(t, doc.Graph.Edges, 24)
+		assert.Equal(t, graphMLNode{ID: "C5", Data: []graphMLData{
+			{Key: "name", Value: "C5"},
+			{Key: "x", Value: "1"},
+			{Key: "y", Value: "1"},
+			{Key: "destroyed", Value: "true"},
+			{Key: "destroyed_iteration", Value: "3"},
+			{Key: "destroyed_by", Value: "1 4"},
+		}}, doc.Graph.Nodes[4])
+		assert.Equal(t, graphMLKey{ID: "destroyed", For: "node", Name: "destroyed", Type: "boolean", Default: "false"}, doc.Keys[3])
+
+		// the map itself is not changed
+		_, exist := wm.cities["C5"]
+		assert.False(t, exist)
+	})
+}
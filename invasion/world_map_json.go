@@ -0,0 +1,128 @@
+package invasion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonWorldMap represents the JSON encoding of a world map.
+type jsonWorldMap struct {
+	Comments []string          `json:"comments,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Cities   []jsonCity        `json:"cities"`
+}
+
+// jsonCity represents the JSON encoding of a city. Links are keyed by
+// direction name (north, south, east or west).
+type jsonCity struct {
+	Name       string            `json:"name"`
+	Links      map[string]string `json:"links,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// DecodeJSON decodes a world map encoded in JSON, for example:
+//
+// 	{
+// 	  "comments": [" Hand-curated map"],
+// 	  "metadata": {"author": "qa"},
+// 	  "cities": [
+// 	    {"name": "C1", "links": {"east": "C2"}, "attributes": {"defense": "3"}},
+// 	    {"name": "C2", "links": {"west": "C1"}}
+// 	  ]
+// 	}
+//
+// The map is validated like in ParseWorldMap, so the same options are accepted.
+// Names, attributes and metadata must be valid in the map file format too,
+// which guarantees that the decoded map can be written with WriteWorldMap.
+func DecodeJSON(r io.Reader, opts ...ParseOption) (*WorldMap, error) {
+	var jm jsonWorldMap
+	if err := json.NewDecoder(r).Decode(&jm); err != nil {
+		return nil, fmt.Errorf("Cannot decode the JSON map: %w", err)
+	}
+
+	b := newMapBuilder(opts)
+	for _, comment := range jm.Comments {
+		if strings.ContainsAny(comment, "\r\n") {
+			return nil, fmt.Errorf("Cannot decode the JSON map: comment %q has line breaks", comment)
+		}
+	}
+	b.wmap.header = jm.Comments
+	for k, v := range jm.Metadata {
+		if err := validateAttrKey(k); err != nil {
+			return nil, fmt.Errorf("Cannot decode the JSON map: %v", err)
+		}
+		if err := validateAttrValue(k, v); err != nil {
+			return nil, fmt.Errorf("Cannot decode the JSON map: %v", err)
+		}
+		b.setMetadata(k, v)
+	}
+	for _, jc := range jm.Cities {
+		data, err := jc.rawCityData()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot decode the JSON map: %v", err)
+		}
+		if err := b.addCity(data); err != nil {
+			return nil, err
+		}
+	}
+	return b.build(), nil
+}
+
+// EncodeJSON encodes the world map in JSON. Cities are sorted by name.
+//
+func EncodeJSON(w io.Writer, m *WorldMap) error {
+	jm := jsonWorldMap{
+		Comments: m.header,
+		Metadata: m.meta,
+		Cities:   make([]jsonCity, 0, len(m.cities)),
+	}
+	for _, c := range m.sortedCities() {
+		jc := jsonCity{Name: c.name, Attributes: c.attrs}
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			if jc.Links == nil {
+				jc.Links = make(map[string]string, 4)
+			}
+			jc.Links[Direction(i).String()] = sc.name
+		}
+		jm.Cities = append(jm.Cities, jc)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jm)
+}
+
+// rawCityData validates the JSON city and converts it into raw city data.
+//
+func (jc jsonCity) rawCityData() (*rawCityData, error) {
+	if err := validateCityName(jc.Name); err != nil {
+		return nil, err
+	}
+	d := &rawCityData{name: jc.Name}
+	for dirName, name := range jc.Links {
+		dir, err := ParseDirection(dirName)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateCityName(name); err != nil {
+			return nil, err
+		}
+		d.dirs[dir] = name
+	}
+	for k, v := range jc.Attributes {
+		if err := validateAttrKey(k); err != nil {
+			return nil, err
+		}
+		if err := validateAttrValue(k, v); err != nil {
+			return nil, err
+		}
+	}
+	if len(jc.Attributes) > 0 {
+		d.attrs = jc.Attributes
+	}
+	return d, nil
+}
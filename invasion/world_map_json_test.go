@@ -0,0 +1,138 @@
+package invasion
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_DecodeJSON(t *testing.T) {
+
+	t.Run("success small map", func(t *testing.T) {
+		f := openTestdataFile(t, "small_map.json")
+		defer f.Close()
+		wm, err := DecodeJSON(f)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, wm))
+		assert.Equal(t, `# @author=qa
+# Small map in JSON
+C1 south=C4 east=C2 @defense=3
+C2 south=C5 east=C3 west=C1
+C3 south=C6 west=C2
+C4 north=C1 south=C7 east=C5
+C5 north=C2 south=C8 east=C6 west=C4
+C6 north=C3 south=C9 west=C5
+C7 north=C4 east=C8
+C8 north=C5 east=C9 west=C7
+C9 north=C6 west=C8
+`, buf.String())
+	})
+
+	t.Run("missing reverse links are filled", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [{"name": "A", "links": {"north": "B"}}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "B south=A", wm.cities["B"].String())
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot decode the JSON map: unexpected EOF")
+	})
+
+	t.Run("invalid direction", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [{"name": "A", "links": {"up": "B"}}]}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot decode the JSON map: up is not a valid direction")
+	})
+
+	t.Run("invalid city name", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [{"name": "A", "links": {"north": "New York"}}]}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, `Cannot decode the JSON map: "New York" is not a valid city name`)
+
+		wm, err = DecodeJSON(strings.NewReader(`{"cities": [{"links": {"north": "B"}}]}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot decode the JSON map: empty city name")
+	})
+
+	t.Run("invalid attributes, metadata and comments", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [{"name": "A", "attributes": {"terrain": "high mountain"}}]}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, `Cannot decode the JSON map: attribute @terrain has an invalid value "high mountain"`)
+
+		wm, err = DecodeJSON(strings.NewReader(`{"metadata": {"a b": "c"}, "cities": []}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot decode the JSON map: @a b is not a valid attribute name")
+
+		wm, err = DecodeJSON(strings.NewReader(`{"comments": ["a\nb"], "cities": []}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, `Cannot decode the JSON map: comment "a\nb" has line breaks`)
+	})
+
+	t.Run("inconsistent map", func(t *testing.T) {
+		wm, err := DecodeJSON(strings.NewReader(`{"cities": [
+			{"name": "A", "links": {"north": "B"}},
+			{"name": "B", "links": {"south": "C"}}
+		]}`))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
+	})
+
+	t.Run("duplicate policy", func(t *testing.T) {
+		data := `{"cities": [{"name": "A", "links": {"north": "B"}}, {"name": "A"}]}`
+		wm, err := DecodeJSON(strings.NewReader(data), WithDuplicatePolicy(DuplicateError))
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: city A is defined more than once")
+	})
+}
+
+func TestWorldMap_EncodeJSON(t *testing.T) {
+
+	t.Run("text to JSON", func(t *testing.T) {
+		wm, err := parseMapString(`# @author=qa
+# Small map in JSON
+C1 south=C4 east=C2 @defense=3
+C2 south=C5 east=C3 west=C1
+C3 south=C6 west=C2
+C4 north=C1 south=C7 east=C5
+C5 north=C2 south=C8 east=C6 west=C4
+C6 north=C3 south=C9 west=C5
+C7 north=C4 east=C8
+C8 north=C5 east=C9 west=C7
+C9 north=C6 west=C8
+`)
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeJSON(buf, wm))
+
+		data, err := os.ReadFile(path.Join("testdata", "small_map.json"))
+		require.NoError(t, err)
+		assert.Equal(t, string(data), buf.String())
+	})
+
+	t.Run("JSON round trip", func(t *testing.T) {
+		wm := parseNormalMap(t)
+		wm.destroyCity("C8")
+		require.NoError(t, wm.SetCityAttribute("C9", "terrain", "mountain"))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeJSON(buf, wm))
+		wm2, err := DecodeJSON(buf)
+		require.NoError(t, err)
+		assert.Equal(t, wm.cities, wm2.cities)
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeJSON(buf, &WorldMap{cities: map[string]*city{}}))
+		assert.Equal(t, "{\n  \"cities\": []\n}\n", buf.String())
+	})
+}
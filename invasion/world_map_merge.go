@@ -0,0 +1,272 @@
+package invasion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CollisionPolicy defines how a city name found in more than one of the
+// merged maps is handled.
+type CollisionPolicy int
+
+// collision policy options
+const (
+	// CollisionError rejects the merge. This is the default policy.
+	CollisionError CollisionPolicy = iota
+	// CollisionMerge handles the cities with the same name as the same city,
+	// joining their roads and attributes. The merge is rejected if both
+	// maps give the city different neighbours at the same direction, or
+	// different values to the same attribute.
+	CollisionMerge
+	// CollisionRename renames the cities of a map whose names are already
+	// taken by a previous map, adding the suffix `_N`, where N is the number
+	// of the map (starting at 1).
+	CollisionRename
+)
+
+// String implements fmt.Stringer.
+func (p CollisionPolicy) String() string {
+	switch p {
+	case CollisionError:
+		return "error"
+	case CollisionMerge:
+		return "merge"
+	case CollisionRename:
+		return "rename"
+	}
+	return "invalid collision policy"
+}
+
+// ParseCollisionPolicy converts a policy name ("error", "merge" or "rename")
+// into a CollisionPolicy. If the name is not valid this will return an error.
+func ParseCollisionPolicy(s string) (CollisionPolicy, error) {
+	switch s {
+	case "error":
+		return CollisionError, nil
+	case "merge":
+		return CollisionMerge, nil
+	case "rename":
+		return CollisionRename, nil
+	}
+	return -1, fmt.Errorf("%s is not a valid collision policy", s)
+}
+
+// MergeOption configures how world maps are merged.
+type MergeOption func(*mergeConfig)
+
+// WithPrefixes adds a prefix to the names of all the cities of each map:
+// the first prefix is used for the first map, and so on. Missing or empty
+// prefixes leave the names of the map unchanged. Prefixes are applied
+// before looking for name collisions.
+func WithPrefixes(prefixes ...string) MergeOption {
+	return func(cfg *mergeConfig) {
+		cfg.prefixes = prefixes
+	}
+}
+
+// WithCollisionPolicy sets the policy used when a city name is found in
+// more than one map.
+func WithCollisionPolicy(p CollisionPolicy) MergeOption {
+	return func(cfg *mergeConfig) {
+		cfg.collisions = p
+	}
+}
+
+// mergeConfig holds the options used to merge world maps.
+type mergeConfig struct {
+	prefixes   []string
+	collisions CollisionPolicy
+}
+
+// MergeMaps combines several world maps into a new one. The cities keep
+// their roads and attributes, and the map metadata of all the maps is
+// joined (the first map defining a key wins). The header comments are the
+// ones of the first map. The merged map is validated with Validate.
+func MergeMaps(maps []*WorldMap, opts ...MergeOption) (*WorldMap, error) {
+	merged, _, err := mergeMaps(maps, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// StitchMaps merges the maps like MergeMaps and joins them with new roads,
+// placing each map at the given side of the previous ones. The maps are
+// joined by the coordinates inferred by Layout: for example, with East,
+// each city at the east edge of a row of the previous maps is connected to
+// the city at the west edge of the same row of the next map. Rows are
+// counted from the top of each map, and columns from the left. All the
+// maps must be embeddable in the plane, and each one must share at least
+// one row (or column, for North and South) with the previous ones.
+func StitchMaps(maps []*WorldMap, side Direction, opts ...MergeOption) (*WorldMap, error) {
+	if !side.valid() {
+		return nil, fmt.Errorf("%d is not a valid direction", side)
+	}
+	for i, m := range maps {
+		if l := m.Layout(); !l.Embedded() {
+			return nil, fmt.Errorf("map %d cannot be stitched: it cannot be embedded in the plane", i+1)
+		}
+	}
+
+	merged, names, err := mergeMaps(maps, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// cities of the maps already stitched
+	stitched := make(map[string]struct{}, merged.NumCities())
+	for i, m := range maps {
+		if i > 0 {
+			if err := stitchMap(merged.subgraph(stitched), m, names[i], side, merged); err != nil {
+				return nil, fmt.Errorf("cannot stitch map %d: %v", i+1, err)
+			}
+		}
+		for _, name := range names[i] {
+			stitched[name] = struct{}{}
+		}
+	}
+
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// mergeMaps merges the maps without validating the result. It also returns
+// the new name of every city of each map.
+func mergeMaps(maps []*WorldMap, opts []MergeOption) (*WorldMap, []map[string]string, error) {
+	cfg := mergeConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	merged := NewWorldMap()
+	names := make([]map[string]string, len(maps))
+	for i, m := range maps {
+		prefix := ""
+		if i < len(cfg.prefixes) {
+			prefix = cfg.prefixes[i]
+		}
+
+		// rename the cities first, so the roads can be added afterwards
+		names[i] = make(map[string]string, len(m.cities))
+		for _, c := range m.fileOrderedCities() {
+			name := prefix + c.name
+			if merged.HasCity(name) {
+				switch cfg.collisions {
+				case CollisionError:
+					return nil, nil, fmt.Errorf("city %s of map %d is already in a previous map", name, i+1)
+				case CollisionRename:
+					name = fmt.Sprintf("%s_%d", name, i+1)
+					if merged.HasCity(name) {
+						return nil, nil, fmt.Errorf("city %s of map %d cannot be renamed, %s is already taken", prefix+c.name, i+1, name)
+					}
+				}
+			}
+			names[i][c.name] = name
+			if !merged.HasCity(name) {
+				if err := merged.AddCity(name); err != nil {
+					return nil, nil, fmt.Errorf("city %s of map %d cannot be added: %v", name, i+1, err)
+				}
+				merged.fileOrder = append(merged.fileOrder, name)
+			}
+		}
+
+		for _, c := range m.sortedCities() {
+			name := names[i][c.name]
+			for _, k := range sortedAttrKeys(c.attrs) {
+				if prev, ok := merged.CityAttribute(name, k); ok && prev != c.attrs[k] {
+					return nil, nil, fmt.Errorf("city %s of map %d has %s%s=%s, but it was %s%s=%s in a previous map",
+						name, i+1, attrPrefix, k, c.attrs[k], attrPrefix, k, prev)
+				}
+				if err := merged.SetCityAttribute(name, k, c.attrs[k]); err != nil {
+					return nil, nil, fmt.Errorf("city %s of map %d cannot be merged: %v", name, i+1, err)
+				}
+			}
+			for dir, sc := range c.dirs {
+				if sc == nil {
+					continue
+				}
+				if err := merged.Connect(name, Direction(dir), names[i][sc.name]); err != nil {
+					return nil, nil, fmt.Errorf("cannot merge map %d: %v", i+1, err)
+				}
+			}
+		}
+
+		if i == 0 {
+			merged.header = append(merged.header, m.header...)
+		}
+		for _, k := range sortedAttrKeys(m.meta) {
+			if _, ok := merged.meta[k]; ok {
+				continue
+			}
+			if err := merged.SetMetadata(k, m.meta[k]); err != nil {
+				return nil, nil, fmt.Errorf("cannot merge the metadata of map %d: %v", i+1, err)
+			}
+		}
+	}
+	return merged, names, nil
+}
+
+// stitchMap connects the cities at the given side of the previous maps to
+// the cities at the opposite side of the map m, whose cities have the given
+// names in the merged map.
+func stitchMap(prev, m *WorldMap, names map[string]string, side Direction, merged *WorldMap) error {
+	if l := prev.Layout(); !l.Embedded() {
+		return errors.New("the previous maps cannot be embedded in the plane")
+	}
+	prevEdge := edgeCities(prev, side)
+	edge := edgeCities(m, side.Opposite())
+	lines := make([]int, 0, len(edge))
+	for line := range edge {
+		if _, ok := prevEdge[line]; ok {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		if side == East || side == West {
+			return errors.New("there are no rows in common with the previous maps")
+		}
+		return errors.New("there are no columns in common with the previous maps")
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		if err := merged.Connect(prevEdge[line], side, names[edge[line]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// edgeCities returns the cities at the given side of each row (for East and
+// West) or column (for North and South) of the map layout, by row or
+// column number. Rows and columns are counted from the top-left corner of
+// the layout.
+func edgeCities(m *WorldMap, side Direction) map[int]string {
+	edge := make(map[int]string)
+	pos := make(map[int]int)
+	for name, p := range m.Layout().Coords {
+		line, v := p.Y, p.X
+		if side == North || side == South {
+			line, v = p.X, p.Y
+		}
+		cur, ok := pos[line]
+		better := v > cur
+		if side == West || side == North {
+			better = v < cur
+		}
+		if !ok || better {
+			edge[line] = name
+			pos[line] = v
+		}
+	}
+	return edge
+}
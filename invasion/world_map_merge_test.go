@@ -0,0 +1,199 @@
+package invasion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMaps(t *testing.T) {
+	writeMap := func(t *testing.T, m *WorldMap) string {
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, m))
+		return buf.String()
+	}
+	parse := func(t *testing.T, s string) *WorldMap {
+		wm, err := parseMapString(s)
+		require.NoError(t, err)
+		return wm
+	}
+
+	t.Run("disjoint maps", func(t *testing.T) {
+		a := parse(t, "# @author=someone\n# Map A\nA1 east=A2 @defense=3\n")
+		b := parse(t, "# @author=other\n# @version=2\n# Map B\nB1 south=B2\n")
+		merged, err := MergeMaps([]*WorldMap{a, b})
+		require.NoError(t, err)
+		assert.Equal(t, "# @author=someone\n# @version=2\n# Map A\nA1 east=A2 @defense=3\nA2 west=A1\nB1 south=B2\nB2 north=B1\n", writeMap(t, merged))
+		assert.Equal(t, []string{"A1", "A2", "B1", "B2"}, merged.fileOrder)
+
+		// the merged maps are not changed
+		assert.Equal(t, 2, a.NumCities())
+		assert.Equal(t, 2, b.NumCities())
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		a := parse(t, "C1 east=C2\n")
+		b := parse(t, "C1 south=C3\n")
+
+		_, err := MergeMaps([]*WorldMap{a, b})
+		assert.EqualError(t, err, "city C1 of map 2 is already in a previous map")
+
+		merged, err := MergeMaps([]*WorldMap{a, b}, WithCollisionPolicy(CollisionMerge))
+		require.NoError(t, err)
+		assert.Equal(t, "C1 south=C3 east=C2\nC2 west=C1\nC3 north=C1\n", writeMap(t, merged))
+
+		merged, err = MergeMaps([]*WorldMap{a, b}, WithCollisionPolicy(CollisionRename))
+		require.NoError(t, err)
+		assert.Equal(t, "C1 east=C2\nC1_2 south=C3\nC2 west=C1\nC3 north=C1_2\n", writeMap(t, merged))
+
+		merged, err = MergeMaps([]*WorldMap{a, b}, WithPrefixes("a.", "b."))
+		require.NoError(t, err)
+		assert.Equal(t, "a.C1 east=a.C2\na.C2 west=a.C1\nb.C1 south=b.C3\nb.C3 north=b.C1\n", writeMap(t, merged))
+
+		merged, err = MergeMaps([]*WorldMap{a, b}, WithPrefixes("", "b."))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"C1", "C2", "b.C1", "b.C3"}, merged.fileOrder)
+
+		_, err = MergeMaps([]*WorldMap{a, b}, WithPrefixes("", "#"))
+		assert.Error(t, err)
+	})
+
+	t.Run("merge conflicts", func(t *testing.T) {
+		a := parse(t, "C1 east=C2 @defense=1\n")
+		_, err := MergeMaps([]*WorldMap{a, parse(t, "C1 east=C3\n")}, WithCollisionPolicy(CollisionMerge))
+		assert.EqualError(t, err, "cannot merge map 2: city C1 already has C2 at its east")
+		_, err = MergeMaps([]*WorldMap{a, parse(t, "C1 @defense=2\n")}, WithCollisionPolicy(CollisionMerge))
+		assert.EqualError(t, err, "city C1 of map 2 has @defense=2, but it was @defense=1 in a previous map")
+
+		_, err = MergeMaps([]*WorldMap{a, parse(t, "C1 @defense=2\n"), parse(t, "C1\n")}, WithCollisionPolicy(CollisionRename))
+		require.NoError(t, err)
+		_, err = MergeMaps([]*WorldMap{a, parse(t, "C1_2\n"), parse(t, "C1\n")}, WithCollisionPolicy(CollisionRename))
+		require.NoError(t, err)
+		_, err = MergeMaps([]*WorldMap{a, parse(t, "C1_2\n"), parse(t, "C1_2\n")}, WithCollisionPolicy(CollisionRename))
+		require.NoError(t, err)
+		_, err = MergeMaps([]*WorldMap{parse(t, "C1\nC1_2\n"), parse(t, "C1\n")}, WithCollisionPolicy(CollisionRename))
+		assert.EqualError(t, err, "city C1 of map 2 cannot be renamed, C1_2 is already taken")
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		// maps built in code can have attributes that cannot be written
+		a := parse(t, "C1 east=C2\n")
+		a.cities["C1"].attrs = map[string]string{"defense": "a b"}
+		_, err := MergeMaps([]*WorldMap{a})
+		assert.EqualError(t, err, `city C1 of map 1 cannot be merged: attribute @defense has an invalid value "a b"`)
+
+		b := parse(t, "C1 east=C2\n")
+		b.meta = map[string]string{"bad key": "x"}
+		_, err = MergeMaps([]*WorldMap{parse(t, "C3\n"), b})
+		assert.EqualError(t, err, "cannot merge the metadata of map 2: @bad key is not a valid attribute name")
+		_, err = StitchMaps([]*WorldMap{parse(t, "C3\n"), b}, East)
+		assert.Error(t, err)
+	})
+
+	t.Run("no maps", func(t *testing.T) {
+		merged, err := MergeMaps(nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, merged.NumCities())
+	})
+}
+
+func TestStitchMaps(t *testing.T) {
+	writeMap := func(t *testing.T, m *WorldMap) string {
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, m))
+		return buf.String()
+	}
+	parse := func(t *testing.T, s string) *WorldMap {
+		wm, err := parseMapString(s)
+		require.NoError(t, err)
+		return wm
+	}
+	// 2x2 grid:
+	// C1 C2
+	// C3 C4
+	grid := "C1 east=C2 south=C3\nC2 south=C4\nC3 east=C4\n"
+
+	t.Run("east", func(t *testing.T) {
+		a, b := parse(t, grid), parse(t, grid)
+		merged, err := StitchMaps([]*WorldMap{a, b}, East, WithPrefixes("a", "b"))
+		require.NoError(t, err)
+		assert.Equal(t, []Road{
+			{From: "aC1", Dir: South, To: "aC3"},
+			{From: "aC1", Dir: East, To: "aC2"},
+			{From: "aC2", Dir: South, To: "aC4"},
+			{From: "aC2", Dir: East, To: "bC1"},
+			{From: "aC3", Dir: East, To: "aC4"},
+			{From: "aC4", Dir: East, To: "bC3"},
+			{From: "bC1", Dir: South, To: "bC3"},
+			{From: "bC1", Dir: East, To: "bC2"},
+			{From: "bC2", Dir: South, To: "bC4"},
+			{From: "bC3", Dir: East, To: "bC4"},
+		}, merged.Roads())
+		assert.True(t, merged.Layout().Embedded())
+	})
+
+	t.Run("three maps", func(t *testing.T) {
+		maps := []*WorldMap{parse(t, grid), parse(t, grid), parse(t, grid)}
+		merged, err := StitchMaps(maps, South, WithCollisionPolicy(CollisionRename))
+		require.NoError(t, err)
+		assert.Equal(t, 12, merged.NumCities())
+		assert.Equal(t, "C1_2", merged.Neighbours("C3")[South])
+		assert.Equal(t, "C2_2", merged.Neighbours("C4")[South])
+		assert.Equal(t, "C1_3", merged.Neighbours("C3_2")[South])
+		assert.Equal(t, "C2_3", merged.Neighbours("C4_2")[South])
+		box := merged.Stats(WithoutDiameter()).BoundingBox
+		assert.Equal(t, 2, box.Width())
+		assert.Equal(t, 6, box.Height())
+	})
+
+	t.Run("north", func(t *testing.T) {
+		a, b := parse(t, grid), parse(t, "D1 east=D2\n")
+		merged, err := StitchMaps([]*WorldMap{a, b}, North)
+		require.NoError(t, err)
+		assert.Equal(t, "D1", merged.Neighbours("C1")[North])
+		assert.Equal(t, "D2", merged.Neighbours("C2")[North])
+		assert.True(t, merged.Layout().Embedded())
+	})
+
+	t.Run("irregular edges", func(t *testing.T) {
+		// the east edge of the first row is C1, and the one of the second
+		// row is C4
+		a := parse(t, "C1 south=C3\nC3 east=C4\n")
+		b := parse(t, "D1 south=D3\nD3 east=D4\n")
+		merged, err := StitchMaps([]*WorldMap{a, b}, East, WithCollisionPolicy(CollisionError))
+		require.NoError(t, err)
+		assert.Equal(t, "D1", merged.Neighbours("C1")[East])
+		assert.Equal(t, "D3", merged.Neighbours("C4")[East])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		a := parse(t, grid)
+		_, err := StitchMaps([]*WorldMap{a, parse(t, grid)}, East)
+		assert.EqualError(t, err, "city C1 of map 2 is already in a previous map")
+
+		_, err = StitchMaps([]*WorldMap{a, parse(t, grid)}, Direction(7))
+		assert.EqualError(t, err, "7 is not a valid direction")
+
+		conflicting := parse(t, "A east=B\nB south=C\nC west=D\nD south=A\n")
+		_, err = StitchMaps([]*WorldMap{a, conflicting}, East)
+		assert.EqualError(t, err, "map 2 cannot be stitched: it cannot be embedded in the plane")
+
+		_, err = StitchMaps([]*WorldMap{a, NewWorldMap()}, East)
+		assert.EqualError(t, err, "cannot stitch map 2: there are no rows in common with the previous maps")
+		_, err = StitchMaps([]*WorldMap{NewWorldMap(), a}, South)
+		assert.EqualError(t, err, "cannot stitch map 2: there are no columns in common with the previous maps")
+	})
+
+	t.Run("partial edge", func(t *testing.T) {
+		// only the first row is in both maps
+		a := parse(t, grid)
+		b := parse(t, "D1\n")
+		merged, err := StitchMaps([]*WorldMap{a, b}, East)
+		require.NoError(t, err)
+		assert.Equal(t, "D1", merged.Neighbours("C2")[East])
+		assert.Equal(t, "", merged.Neighbours("C4")[East])
+		assert.Equal(t, "C1 south=C3 east=C2\nC2 south=C4 east=D1 west=C1\nC3 north=C1 east=C4\nC4 north=C2 west=C3\nD1 west=C2\n", writeMap(t, merged))
+	})
+}
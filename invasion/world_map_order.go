@@ -0,0 +1,127 @@
+package invasion
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CityOrder defines the order of the cities when a world map is written
+// with WriteTo, e.g. in the result map of Start.
+type CityOrder int
+
+// city order options
+const (
+	// OrderByName sorts the cities by name, comparing the strings byte by
+	// byte (C1, C10, C2). This is the default order.
+	OrderByName CityOrder = iota
+	// OrderNatural sorts the cities by name, comparing the numbers in the
+	// names by their value (C1, C2, C10).
+	OrderNatural
+	// OrderFile keeps the order of the map file. The cities that are only
+	// referenced as surrounding cities go after the defined ones, and the
+	// cities added to the map by other means go last, in natural order.
+	OrderFile
+	// OrderCoordinates sorts the cities by the coordinates inferred by
+	// Layout, from north to south and then from west to east. The cities
+	// without coordinates go last, in natural order.
+	OrderCoordinates
+)
+
+// String implements fmt.Stringer.
+func (o CityOrder) String() string {
+	switch o {
+	case OrderByName:
+		return "name"
+	case OrderNatural:
+		return "natural"
+	case OrderFile:
+		return "file"
+	case OrderCoordinates:
+		return "coordinates"
+	}
+	return "invalid city order"
+}
+
+// ParseCityOrder converts an order name ("name", "natural", "file" or
+// "coordinates") into a CityOrder. If the name is not valid this will
+// return an error.
+func ParseCityOrder(s string) (CityOrder, error) {
+	switch s {
+	case "name":
+		return OrderByName, nil
+	case "natural":
+		return OrderNatural, nil
+	case "file":
+		return OrderFile, nil
+	case "coordinates":
+		return OrderCoordinates, nil
+	}
+	return -1, fmt.Errorf("%s is not a valid city order", s)
+}
+
+// SetCityOrder sets the order of the cities written by WriteTo.
+//
+func (m *WorldMap) SetCityOrder(o CityOrder) {
+	m.order = o
+}
+
+// orderedCities returns the cities of the map in the order set with
+// SetCityOrder.
+func (m *WorldMap) orderedCities() []*city {
+	switch m.order {
+	case OrderNatural:
+		return m.naturalSortedCities()
+	case OrderFile:
+		return m.fileOrderedCities()
+	case OrderCoordinates:
+		return m.coordinateSortedCities()
+	}
+	return m.sortedCities()
+}
+
+// fileOrderedCities returns the cities of the map in the order they were
+// read, followed by the cities that were not read, in natural order.
+func (m *WorldMap) fileOrderedCities() []*city {
+	cs := make([]*city, 0, len(m.cities))
+	seen := make(map[string]struct{}, len(m.cities))
+	for _, name := range m.fileOrder {
+		c, ok := m.cities[name]
+		if !ok {
+			continue
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		cs = append(cs, c)
+	}
+	if len(cs) == len(m.cities) {
+		return cs
+	}
+	for _, c := range m.naturalSortedCities() {
+		if _, ok := seen[c.name]; !ok {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// coordinateSortedCities returns the cities of the map sorted by their
+// layout coordinates, followed by the cities without coordinates in
+// natural order.
+func (m *WorldMap) coordinateSortedCities() []*city {
+	coords := m.Layout().Coords
+	cs := m.naturalSortedCities()
+	sort.SliceStable(cs, func(i, j int) bool {
+		pi, iok := coords[cs[i].name]
+		pj, jok := coords[cs[j].name]
+		if !iok || !jok {
+			return iok && !jok
+		}
+		if pi.Y != pj.Y {
+			return pi.Y < pj.Y
+		}
+		return pi.X < pj.X
+	})
+	return cs
+}
@@ -0,0 +1,79 @@
+package invasion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCityOrder(t *testing.T) {
+	for _, o := range []CityOrder{OrderByName, OrderNatural, OrderFile, OrderCoordinates} {
+		got, err := ParseCityOrder(o.String())
+		assert.NoError(t, err)
+		assert.Equal(t, o, got)
+	}
+	_, err := ParseCityOrder("random")
+	assert.EqualError(t, err, "random is not a valid city order")
+	assert.Equal(t, "invalid city order", CityOrder(-1).String())
+}
+
+func TestWorldMap_WriteTo(t *testing.T) {
+	wm, err := parseMapString(`C10 west=C9
+C2 east=C3 south=C10
+C9 north=C1
+C3
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		order CityOrder
+		want  string
+	}{
+		{OrderByName, "C1 south=C9\nC10 north=C2 west=C9\nC2 south=C10 east=C3\nC3 west=C2\nC9 north=C1 east=C10\n"},
+		{OrderNatural, "C1 south=C9\nC2 south=C10 east=C3\nC3 west=C2\nC9 north=C1 east=C10\nC10 north=C2 west=C9\n"},
+		{OrderFile, "C10 north=C2 west=C9\nC2 south=C10 east=C3\nC9 north=C1 east=C10\nC3 west=C2\nC1 south=C9\n"},
+		{OrderCoordinates, "C1 south=C9\nC2 south=C10 east=C3\nC3 west=C2\nC9 north=C1 east=C10\nC10 north=C2 west=C9\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			wm.SetCityOrder(tt.order)
+			buf := &bytes.Buffer{}
+			n, err := wm.WriteTo(buf)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+			assert.Equal(t, int64(buf.Len()), n)
+		})
+	}
+
+	t.Run("file order after changes", func(t *testing.T) {
+		wm.SetCityOrder(OrderFile)
+		wm.destroyCity("C2")
+		wm.getOrCreateCity("C0")
+		buf := &bytes.Buffer{}
+		_, err := wm.WriteTo(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "C10 west=C9\nC9 north=C1 east=C10\nC3\nC1 south=C9\nC0\n", buf.String())
+	})
+
+	t.Run("cities without coordinates go last", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB south=C\nC west=D\nD south=A\nE east=F\n")
+		require.NoError(t, err)
+		wm.SetCityOrder(OrderCoordinates)
+		buf := &bytes.Buffer{}
+		_, err = wm.WriteTo(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "E east=F\nF west=E\nA north=D east=B\nB south=C west=A\nC north=B west=D\nD south=A east=C\n", buf.String())
+	})
+
+	t.Run("binary maps keep the file order", func(t *testing.T) {
+		wm, err := parseMapString("C3 west=C2\nC1 east=C2\n")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, EncodeBinary(buf, wm))
+		wm2, err := DecodeBinary(buf)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"C3", "C1", "C2"}, wm2.fileOrder)
+	})
+}
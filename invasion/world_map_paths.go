@@ -0,0 +1,152 @@
+package invasion
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoPath is returned by ShortestPath when the cities are not connected.
+var ErrNoPath = errors.New("there is no path between the cities")
+
+// ShortestPath returns the directions to follow to go from a city to
+// another one using the fewest roads. If both cities are the same, the path
+// is empty. If the cities are not connected, this returns ErrNoPath. Among
+// the shortest paths, the one returned prefers the directions in the order
+// north, south, east and west.
+func (m *WorldMap) ShortestPath(from, to string) ([]Direction, error) {
+	src, dst, err := m.pathEnds(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// breadth-first search keeping how each city was reached
+	type step struct {
+		prev *city
+		dir  Direction
+	}
+	steps := map[*city]step{src: {}}
+	queue := []*city{src}
+	for len(queue) > 0 && queue[0] != dst {
+		c := queue[0]
+		queue = queue[1:]
+		for i, sc := range c.dirs {
+			if sc == nil {
+				continue
+			}
+			if _, ok := steps[sc]; ok {
+				continue
+			}
+			steps[sc] = step{prev: c, dir: Direction(i)}
+			queue = append(queue, sc)
+		}
+	}
+	if _, ok := steps[dst]; !ok {
+		return nil, ErrNoPath
+	}
+
+	var path []Direction
+	for c := dst; c != src; c = steps[c].prev {
+		path = append(path, steps[c].dir)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// Distance returns the number of roads of the shortest path between two
+// cities. If the cities are not connected, this returns ErrNoPath.
+func (m *WorldMap) Distance(from, to string) (int, error) {
+	path, err := m.ShortestPath(from, to)
+	return len(path), err
+}
+
+// Distances returns the distance from a city to every city reachable from
+// it, including itself. If the city does not exist, this returns nil.
+func (m *WorldMap) Distances(from string) map[string]int {
+	c, ok := m.cities[from]
+	if !ok {
+		return nil
+	}
+	return c.distances()
+}
+
+// AllPairsDistances returns the distances between every pair of connected
+// cities, by city name. It runs a breadth-first search from each city, so it
+// is meant for small maps.
+func (m *WorldMap) AllPairsDistances() map[string]map[string]int {
+	dists := make(map[string]map[string]int, len(m.cities))
+	for name, c := range m.cities {
+		dists[name] = c.distances()
+	}
+	return dists
+}
+
+// Eccentricity returns the greatest distance from a city to any city
+// reachable from it.
+func (m *WorldMap) Eccentricity(name string) (int, error) {
+	c, ok := m.cities[name]
+	if !ok {
+		return 0, fmt.Errorf("city %s does not exist", name)
+	}
+	return c.eccentricity(), nil
+}
+
+// Diameter returns the greatest distance between two connected cities of
+// the map. Cities of different connected components are not connected, so
+// for maps with many components this is the diameter of the widest one.
+// Like AllPairsDistances, it runs a breadth-first search from each city.
+func (m *WorldMap) Diameter() int {
+	diameter := 0
+	for _, c := range m.cities {
+		diameter = maxInt(diameter, c.eccentricity())
+	}
+	return diameter
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// pathEnds returns the cities at both ends of a path.
+//
+func (m *WorldMap) pathEnds(from, to string) (*city, *city, error) {
+	src, ok := m.cities[from]
+	if !ok {
+		return nil, nil, fmt.Errorf("city %s does not exist", from)
+	}
+	dst, ok := m.cities[to]
+	if !ok {
+		return nil, nil, fmt.Errorf("city %s does not exist", to)
+	}
+	return src, dst, nil
+}
+
+// distances returns the distance from this city to every city reachable
+// from it, including itself.
+func (c *city) distances() map[string]int {
+	dists := map[string]int{c.name: 0}
+	queue := []*city{c}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, sc := range cur.surroundingCities() {
+			if _, ok := dists[sc.name]; ok {
+				continue
+			}
+			dists[sc.name] = dists[cur.name] + 1
+			queue = append(queue, sc)
+		}
+	}
+	return dists
+}
+
+// eccentricity returns the greatest distance from this city to any city
+// reachable from it.
+func (c *city) eccentricity() int {
+	ecc := 0
+	for _, d := range c.distances() {
+		ecc = maxInt(ecc, d)
+	}
+	return ecc
+}
@@ -0,0 +1,83 @@
+package invasion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_ShortestPath(t *testing.T) {
+	wm := parseSmallMap(t)
+
+	t.Run("path between corners", func(t *testing.T) {
+		path, err := wm.ShortestPath("C1", "C9")
+		require.NoError(t, err)
+		assert.Equal(t, []Direction{South, South, East, East}, path)
+
+		path, err = wm.ShortestPath("C9", "C2")
+		require.NoError(t, err)
+		assert.Equal(t, []Direction{North, North, West}, path)
+
+		d, err := wm.Distance("C3", "C7")
+		require.NoError(t, err)
+		assert.Equal(t, 4, d)
+	})
+
+	t.Run("same city", func(t *testing.T) {
+		path, err := wm.ShortestPath("C5", "C5")
+		require.NoError(t, err)
+		assert.Empty(t, path)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := wm.ShortestPath("C0", "C5")
+		assert.EqualError(t, err, "city C0 does not exist")
+		_, err = wm.ShortestPath("C5", "C0")
+		assert.EqualError(t, err, "city C0 does not exist")
+
+		wm := parseSmallMap(t)
+		wm.destroyCity("C2")
+		wm.destroyCity("C5")
+		wm.destroyCity("C8")
+		_, err = wm.ShortestPath("C1", "C9")
+		assert.Equal(t, ErrNoPath, err)
+		_, err = wm.Distance("C1", "C9")
+		assert.Equal(t, ErrNoPath, err)
+	})
+}
+
+func TestWorldMap_Distances(t *testing.T) {
+	wm := parseSmallMap(t)
+
+	assert.Equal(t, map[string]int{
+		"C1": 2, "C2": 1, "C3": 2,
+		"C4": 1, "C5": 0, "C6": 1,
+		"C7": 2, "C8": 1, "C9": 2,
+	}, wm.Distances("C5"))
+	assert.Nil(t, wm.Distances("C0"))
+
+	all := wm.AllPairsDistances()
+	assert.Len(t, all, 9)
+	assert.Equal(t, 4, all["C1"]["C9"])
+	assert.Equal(t, all["C5"], wm.Distances("C5"))
+
+	ecc, err := wm.Eccentricity("C5")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ecc)
+	ecc, err = wm.Eccentricity("C1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, ecc)
+	_, err = wm.Eccentricity("C0")
+	assert.EqualError(t, err, "city C0 does not exist")
+
+	assert.Equal(t, 4, wm.Diameter())
+
+	t.Run("many components", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB east=C\nD south=E\nF\n")
+		require.NoError(t, err)
+		assert.Equal(t, 2, wm.Diameter())
+		assert.Equal(t, map[string]int{"D": 0, "E": 1}, wm.AllPairsDistances()["D"])
+		assert.Equal(t, 0, NewWorldMap().Diameter())
+	})
+}
@@ -0,0 +1,143 @@
+package invasion
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLineLength is the default maximum length in bytes of a map line
+// read by ReadWorldMap, without the line ending.
+const DefaultMaxLineLength = 1 << 20
+
+// MaxLineLengthLimit is the greatest maximum line length accepted by
+// WithMaxLineLength. Greater values are lowered to it.
+const MaxLineLengthLimit = 1 << 30
+
+// progressInterval is the minimum number of bytes read between two progress
+// reports.
+const progressInterval = 1 << 20
+
+// WithMaxLineLength sets the maximum length in bytes of a map line, without
+// the line ending. It must be greater than 0, or ReadWorldMap returns an
+// error, and it is lowered to MaxLineLengthLimit if it is greater. It is
+// only used by ReadWorldMap, ParseWorldMap uses the limit of its scanner.
+func WithMaxLineLength(n int) ParseOption {
+	return func(cfg *parseConfig) {
+		if n > MaxLineLengthLimit {
+			n = MaxLineLengthLimit
+		}
+		cfg.maxLineLength = n
+	}
+}
+
+// WithProgress sets a function that receives the number of bytes read so
+// far. It is called about every megabyte and once after the whole map has
+// been read. It is only used by ReadWorldMap and ReadWorldMapFile.
+func WithProgress(fn func(bytesRead int64)) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.progress = fn
+	}
+}
+
+// LineTooLongError is returned by ReadWorldMap when a map line is longer
+// than the maximum line length.
+type LineTooLongError struct {
+	// Line is the number of the line, starting from 1.
+	Line int
+	// Offset is the byte offset where the line starts.
+	Offset int64
+	// Max is the maximum line length.
+	Max int
+}
+
+// Error implements the error interface.
+//
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("Cannot parse the map: line %d (byte offset %d) is longer than %d bytes", e.Line, e.Offset, e.Max)
+}
+
+// ReadWorldMap parses the simulated world map from a reader, like
+// ParseWorldMap. Lines can be up to DefaultMaxLineLength bytes long, see
+// WithMaxLineLength to change it, and WithProgress can be used to report
+// the progress of big maps.
+func ReadWorldMap(r io.Reader, opts ...ParseOption) (*WorldMap, error) {
+	b := newMapBuilder(opts)
+	if b.cfg.maxLineLength <= 0 {
+		return nil, fmt.Errorf("Cannot parse the map: the maximum line length must be greater than 0, got %d", b.cfg.maxLineLength)
+	}
+	if b.cfg.progress != nil {
+		r = &progressReader{r: r, report: b.cfg.progress}
+	}
+
+	ls := &lineSplitter{max: b.cfg.maxLineLength}
+	s := bufio.NewScanner(r)
+	// the buffer has room for the longest line and its CRLF ending, so a
+	// full buffer always means that the line is too long
+	s.Buffer(make([]byte, 0, minInt(ls.max+2, bufio.MaxScanTokenSize)), ls.max+2)
+	s.Split(ls.split)
+
+	err := parseMapLines(s, b)
+	if s.Err() == bufio.ErrTooLong {
+		return nil, ls.tooLong()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.build(), nil
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// lineSplitter splits lines like bufio.ScanLines, keeping track of the
+// line number and byte offset to report the lines that are too long.
+type lineSplitter struct {
+	max    int
+	line   int
+	offset int64
+}
+
+// split implements bufio.SplitFunc.
+//
+func (ls *lineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if err != nil || advance == 0 {
+		return advance, token, err
+	}
+	if len(bytes.TrimSuffix(token, []byte("\r"))) > ls.max {
+		return 0, nil, ls.tooLong()
+	}
+	ls.line++
+	ls.offset += int64(advance)
+	return advance, token, nil
+}
+
+// tooLong returns the error of the line being split.
+//
+func (ls *lineSplitter) tooLong() error {
+	return &LineTooLongError{Line: ls.line + 1, Offset: ls.offset, Max: ls.max}
+}
+
+// progressReader reports the number of bytes read from r.
+//
+type progressReader struct {
+	r        io.Reader
+	report   func(bytesRead int64)
+	read     int64
+	reported int64
+}
+
+// Read implements io.Reader.
+//
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.read += int64(n)
+	if p.read-p.reported >= progressInterval || (err == io.EOF && p.read != p.reported) {
+		p.reported = p.read
+		p.report(p.read)
+	}
+	return n, err
+}
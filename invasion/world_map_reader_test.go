@@ -0,0 +1,109 @@
+package invasion
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_ReadWorldMap(t *testing.T) {
+
+	t.Run("same result as ParseWorldMap", func(t *testing.T) {
+		f := openTestdataFile(t, "commented_map.txt")
+		defer f.Close()
+		wm, err := ReadWorldMap(f)
+		require.NoError(t, err)
+		assert.Equal(t, parseSmallMap(t).cities, wm.cities)
+		assert.Len(t, wm.header, 2)
+	})
+
+	t.Run("lines longer than the scanner limit", func(t *testing.T) {
+		longName := strings.Repeat("A", 100*1024)
+		data := "C1 east=" + longName + "\n"
+
+		_, err := parseMapString(data)
+		assert.True(t, errors.Is(err, bufio.ErrTooLong))
+		assert.EqualError(t, err, "Cannot parse the map: bufio.Scanner: token too long")
+
+		wm, err := ReadWorldMap(strings.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, longName, wm.cities["C1"].dirs[East].name)
+	})
+
+	t.Run("line too long", func(t *testing.T) {
+		data := "C1 east=C2\r\n# comment\nC3 north=C1 east=C4\nC4\n"
+		_, err := ReadWorldMap(strings.NewReader(data), WithMaxLineLength(10))
+		assert.EqualError(t, err, "Cannot parse the map: line 3 (byte offset 22) is longer than 10 bytes")
+		var lineErr *LineTooLongError
+		require.True(t, errors.As(err, &lineErr))
+		assert.Equal(t, LineTooLongError{Line: 3, Offset: 22, Max: 10}, *lineErr)
+
+		// the CRLF ending is not counted
+		wm, err := ReadWorldMap(strings.NewReader(data), WithMaxLineLength(19))
+		require.NoError(t, err)
+		assert.Len(t, wm.cities, 4)
+	})
+
+	t.Run("invalid max line length", func(t *testing.T) {
+		for _, n := range []int{0, -5} {
+			_, err := ReadWorldMap(strings.NewReader("C1 east=C2\n"), WithMaxLineLength(n))
+			assert.EqualError(t, err, fmt.Sprintf("Cannot parse the map: the maximum line length must be greater than 0, got %d", n))
+		}
+
+		// huge limits are lowered
+		cfg := newParseConfig([]ParseOption{WithMaxLineLength(math.MaxInt)})
+		assert.Equal(t, MaxLineLengthLimit, cfg.maxLineLength)
+		wm, err := ReadWorldMap(strings.NewReader("C1 east=C2\n"), WithMaxLineLength(math.MaxInt))
+		require.NoError(t, err)
+		assert.Len(t, wm.cities, 2)
+	})
+
+	t.Run("line too long filling the buffer", func(t *testing.T) {
+		data := "C1 east=C2\n" + strings.Repeat("C", 200)
+		_, err := ReadWorldMap(strings.NewReader(data), WithMaxLineLength(100))
+		assert.EqualError(t, err, "Cannot parse the map: line 2 (byte offset 11) is longer than 100 bytes")
+	})
+
+	t.Run("read errors are wrapped", func(t *testing.T) {
+		errRead := errors.New("disk failure")
+		_, err := ReadWorldMap(&failingReader{err: errRead})
+		assert.True(t, errors.Is(err, errRead))
+		assert.EqualError(t, err, "Cannot parse the map: disk failure")
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		var sb strings.Builder
+		for sb.Len() < 3*progressInterval {
+			sb.WriteString("# filler comment line\n")
+		}
+		sb.WriteString("C1 east=C2\n")
+		var reports []int64
+		_, err := ReadWorldMap(strings.NewReader(sb.String()), WithProgress(func(n int64) {
+			reports = append(reports, n)
+		}))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(reports), 3)
+		assert.Equal(t, int64(sb.Len()), reports[len(reports)-1])
+		for i := 1; i < len(reports); i++ {
+			assert.Greater(t, reports[i], reports[i-1])
+		}
+	})
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,236 @@
+package invasion
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CityMapping maps city names to new names. Cities that are not in the
+// mapping keep their names.
+type CityMapping map[string]string
+
+// destroyedLineRe matches the lines written by Start when a city is
+// destroyed.
+var destroyedLineRe = regexp.MustCompile(`^(\S+)( has been destroyed by .*!)$`)
+
+// Name returns the new name of a city.
+//
+func (cm CityMapping) Name(name string) string {
+	if n, ok := cm[name]; ok {
+		return n
+	}
+	return name
+}
+
+// Inverse returns the mapping that restores the original names. If two
+// cities are mapped to the same name this will return an error.
+func (cm CityMapping) Inverse() (CityMapping, error) {
+	inv := make(CityMapping, len(cm))
+	for _, old := range cm.sortedNames() {
+		n := cm[old]
+		if prev, ok := inv[n]; ok {
+			return nil, fmt.Errorf("cities %s and %s are both mapped to %s", prev, old, n)
+		}
+		inv[n] = old
+	}
+	return inv, nil
+}
+
+// ReadCityMapping reads a city mapping with one city per line: the current
+// name and the new name, separated by spaces. Empty lines and lines
+// starting with '#' are ignored.
+func ReadCityMapping(r io.Reader) (CityMapping, error) {
+	cm := make(CityMapping)
+	s := bufio.NewScanner(r)
+	lineNum := 0
+	for s.Scan() {
+		lineNum++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Cannot parse the mapping: line %d should have the current and the new city name", lineNum)
+		}
+		for _, name := range fields {
+			if err := validateCityName(name); err != nil {
+				return nil, fmt.Errorf("Cannot parse the mapping: line %d: %v", lineNum, err)
+			}
+		}
+		if _, ok := cm[fields[0]]; ok {
+			return nil, fmt.Errorf("Cannot parse the mapping: line %d: city %s is mapped more than once", lineNum, fields[0])
+		}
+		cm[fields[0]] = fields[1]
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot parse the mapping: %w", err)
+	}
+	return cm, nil
+}
+
+// WriteCityMapping writes a city mapping in the format read by
+// ReadCityMapping, sorted by the current name in natural order.
+func WriteCityMapping(w io.Writer, cm CityMapping) error {
+	bw := bufio.NewWriter(w)
+	for _, name := range cm.sortedNames() {
+		fmt.Fprintf(bw, "%s %s\n", name, cm[name])
+	}
+	return bw.Flush()
+}
+
+// RegexpMapping returns the mapping that renames the cities of the map
+// matching the regular expression, replacing the matches with repl like in
+// regexp.Regexp.ReplaceAllString. Cities whose names do not change are not
+// included.
+func (m *WorldMap) RegexpMapping(re *regexp.Regexp, repl string) CityMapping {
+	cm := make(CityMapping)
+	for name := range m.cities {
+		if n := re.ReplaceAllString(name, repl); n != name {
+			cm[name] = n
+		}
+	}
+	return cm
+}
+
+// AnonymousMapping returns the mapping that renames every city of the map
+// to the prefix followed by a number, from 1 to the number of cities. The
+// numbers are shuffled with the given seed, so the new names reveal nothing
+// about the original ones.
+func (m *WorldMap) AnonymousMapping(prefix string, seed int64) CityMapping {
+	cities := m.naturalSortedCities()
+	ids := rand.New(rand.NewSource(seed)).Perm(len(cities))
+	cm := make(CityMapping, len(cities))
+	for i, c := range cities {
+		cm[c.name] = fmt.Sprintf("%s%d", prefix, ids[i]+1)
+	}
+	return cm
+}
+
+// Rename returns a copy of the map with the cities renamed by the mapping.
+// The new names must be valid city names, and two cities cannot end up
+// with the same name. The attributes of the cities, the map metadata and
+// the header comments are kept.
+func (m *WorldMap) Rename(cm CityMapping) (*WorldMap, error) {
+	owners := make(map[string]string, len(m.cities))
+	for _, c := range m.sortedCities() {
+		n := cm.Name(c.name)
+		if err := validateCityName(n); err != nil {
+			return nil, fmt.Errorf("city %s cannot be renamed: %v", c.name, err)
+		}
+		if prev, ok := owners[n]; ok {
+			return nil, fmt.Errorf("cities %s and %s would both be named %s", prev, c.name, n)
+		}
+		owners[n] = c.name
+	}
+
+	renamed := NewWorldMap()
+	renamed.header = append(renamed.header, m.header...)
+	if len(m.meta) > 0 {
+		renamed.meta = make(map[string]string, len(m.meta))
+		for k, v := range m.meta {
+			renamed.meta[k] = v
+		}
+	}
+	for _, name := range m.fileOrder {
+		renamed.fileOrder = append(renamed.fileOrder, cm.Name(name))
+	}
+	renamed.order = m.order
+
+	for name, c := range m.cities {
+		rc := renamed.getOrCreateCity(cm.Name(name))
+		for k, v := range c.attrs {
+			if rc.attrs == nil {
+				rc.attrs = make(map[string]string, len(c.attrs))
+			}
+			rc.attrs[k] = v
+		}
+		for i, sc := range c.dirs {
+			if sc != nil {
+				rc.dirs[i] = renamed.getOrCreateCity(cm.Name(sc.name))
+			}
+		}
+	}
+	return renamed, nil
+}
+
+// TranslateResult renames the cities of a result written by Start: the
+// destroyed city lines and the result map. The other lines are copied
+// unchanged, and the lines keep their order. CRLF line endings are read, but
+// the lines are always written with LF. Used with the inverse mapping, the
+// result of an invasion of a renamed map can be translated back to the
+// original names.
+func TranslateResult(r io.Reader, w io.Writer, cm CityMapping) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, DefaultMaxLineLength)
+	bw := bufio.NewWriter(w)
+	inResultMap := false
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		switch {
+		case line == resultMapHeader:
+			inResultMap = true
+		case inResultMap:
+			line = translateMapLine(line, cm)
+		default:
+			if sm := destroyedLineRe.FindStringSubmatch(line); sm != nil {
+				line = cm.Name(sm[1]) + sm[2]
+			}
+		}
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("Cannot translate the result: a line is longer than %d bytes", DefaultMaxLineLength)
+		}
+		return fmt.Errorf("Cannot translate the result: %w", err)
+	}
+	return bw.Flush()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// sortedNames returns the current names of the mapping in natural order.
+//
+func (cm CityMapping) sortedNames() []string {
+	names := make([]string, 0, len(cm))
+	for name := range cm {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return naturalLess(names[i], names[j])
+	})
+	return names
+}
+
+// translateMapLine renames the city and the linked cities of a map line.
+// Comments, attributes, blank lines and the empty map line are not changed.
+func translateMapLine(line string, cm CityMapping) string {
+	if line == "" || line == noCitiesLine || strings.HasPrefix(line, "#") {
+		return line
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return line
+	}
+	fields[0] = cm.Name(fields[0])
+	for i := 1; i < len(fields); i++ {
+		k, v, ok := strings.Cut(fields[i], "=")
+		if !ok {
+			continue
+		}
+		if _, err := ParseDirection(k); err == nil {
+			fields[i] = k + "=" + cm.Name(v)
+		}
+	}
+	return strings.Join(fields, " ")
+}
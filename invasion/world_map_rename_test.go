@@ -0,0 +1,145 @@
+package invasion
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCityMapping(t *testing.T) {
+	t.Run("read and write", func(t *testing.T) {
+		cm, err := ReadCityMapping(strings.NewReader("# mapping\nC10 X10\n\n  C2   X2\nC1 X1\n"))
+		require.NoError(t, err)
+		assert.Equal(t, CityMapping{"C1": "X1", "C2": "X2", "C10": "X10"}, cm)
+		assert.Equal(t, "X2", cm.Name("C2"))
+		assert.Equal(t, "C3", cm.Name("C3"))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteCityMapping(buf, cm))
+		assert.Equal(t, "C1 X1\nC2 X2\nC10 X10\n", buf.String())
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		_, err := ReadCityMapping(strings.NewReader("C1 X1\nC2\n"))
+		assert.EqualError(t, err, "Cannot parse the mapping: line 2 should have the current and the new city name")
+		_, err = ReadCityMapping(strings.NewReader("C1 X=1\n"))
+		assert.EqualError(t, err, `Cannot parse the mapping: line 1: "X=1" is not a valid city name`)
+		_, err = ReadCityMapping(strings.NewReader("C1 X1\nC1 X2\n"))
+		assert.EqualError(t, err, "Cannot parse the mapping: line 2: city C1 is mapped more than once")
+	})
+
+	t.Run("inverse", func(t *testing.T) {
+		inv, err := CityMapping{"C1": "X1", "C2": "X2"}.Inverse()
+		require.NoError(t, err)
+		assert.Equal(t, CityMapping{"X1": "C1", "X2": "C2"}, inv)
+
+		_, err = CityMapping{"C1": "X", "C2": "X"}.Inverse()
+		assert.EqualError(t, err, "cities C1 and C2 are both mapped to X")
+	})
+}
+
+func TestWorldMap_Rename(t *testing.T) {
+	writeMap := func(t *testing.T, m *WorldMap) string {
+		buf := &bytes.Buffer{}
+		require.NoError(t, WriteWorldMap(buf, m))
+		return buf.String()
+	}
+
+	t.Run("mapping", func(t *testing.T) {
+		wm, err := parseMapString("# @author=someone\nC1 east=C2 @defense=3\nC2 south=C3\n")
+		require.NoError(t, err)
+		renamed, err := wm.Rename(CityMapping{"C1": "Foo", "C3": "Bar"})
+		require.NoError(t, err)
+		assert.Equal(t, "# @author=someone\nBar north=C2\nC2 south=Bar west=Foo\nFoo east=C2 @defense=3\n", writeMap(t, renamed))
+		assert.Equal(t, []string{"Foo", "C2", "Bar"}, renamed.fileOrder)
+		require.NoError(t, renamed.Validate())
+
+		// the original map is not changed
+		assert.Equal(t, []string{"C1", "C2", "C3"}, wm.Cities())
+
+		_, err = wm.Rename(CityMapping{"C1": "C2"})
+		assert.EqualError(t, err, "cities C1 and C2 would both be named C2")
+		_, err = wm.Rename(CityMapping{"C1": "#C1"})
+		assert.EqualError(t, err, `city C1 cannot be renamed: "#C1" is not a valid city name`)
+
+		// swapping names is allowed
+		renamed, err = wm.Rename(CityMapping{"C1": "C2", "C2": "C1"})
+		require.NoError(t, err)
+		assert.Equal(t, "# @author=someone\nC1 south=C3 west=C2\nC2 east=C1 @defense=3\nC3 north=C1\n", writeMap(t, renamed))
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		cm := wm.RegexpMapping(regexp.MustCompile(`^C([1-3])$`), "Top$1")
+		assert.Equal(t, CityMapping{"C1": "Top1", "C2": "Top2", "C3": "Top3"}, cm)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		wm := parseSmallMap(t)
+		cm := wm.AnonymousMapping("N", 42)
+		assert.Len(t, cm, 9)
+		assert.Equal(t, cm, wm.AnonymousMapping("N", 42))
+		inv, err := cm.Inverse()
+		require.NoError(t, err)
+		for i := 1; i <= 9; i++ {
+			assert.Contains(t, inv, "N"+strconv.Itoa(i))
+		}
+
+		renamed, err := wm.Rename(cm)
+		require.NoError(t, err)
+		restored, err := renamed.Rename(inv)
+		require.NoError(t, err)
+		assert.Equal(t, writeMap(t, wm), writeMap(t, restored))
+	})
+}
+
+func TestTranslateResult(t *testing.T) {
+	src, err := os.ReadFile("testdata/result_case_1.txt")
+	require.NoError(t, err)
+	cm := CityMapping{"C2": "B", "C5": "E", "C8": "H", "C9": "I"}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, TranslateResult(bytes.NewReader(src), buf, cm))
+	assert.Equal(t, `B has been destroyed by alien 1 and alien 3!
+E has been destroyed by alien 0, alien 2 and alien 4!
+All the aliens have been destroyed!
+
+Result map:
+C1 south=C4
+C3 south=C6
+C4 north=C1 south=C7
+C6 north=C3 south=I
+C7 north=C4 east=H
+H east=I west=C7
+I north=C6 west=H
+`, buf.String())
+
+	// translating it back gives the original result
+	inv, err := cm.Inverse()
+	require.NoError(t, err)
+	back := &bytes.Buffer{}
+	require.NoError(t, TranslateResult(buf, back, inv))
+	assert.Equal(t, strings.TrimSuffix(string(src), "\n")+"\n", back.String())
+
+	// an empty result map is not changed
+	buf.Reset()
+	require.NoError(t, TranslateResult(strings.NewReader("Result map:\n"+noCitiesLine+"\n"), buf, cm))
+	assert.Equal(t, "Result map:\n"+noCitiesLine+"\n", buf.String())
+
+	// blank lines in the result map are not changed
+	buf.Reset()
+	require.NoError(t, TranslateResult(strings.NewReader("Result map:\nC2 east=C5\n \t\nC5 west=C2\n"), buf, cm))
+	assert.Equal(t, "Result map:\nB east=E\n \t\nE west=B\n", buf.String())
+
+	// CRLF results are translated
+	buf.Reset()
+	crlf := "C2 has been destroyed by alien 1 and alien 3!\r\n\r\nResult map:\r\r\nC5 east=C8\r\n"
+	require.NoError(t, TranslateResult(strings.NewReader(crlf), buf, cm))
+	assert.Equal(t, "B has been destroyed by alien 1 and alien 3!\n\nResult map:\nE east=H\n", buf.String())
+}
@@ -0,0 +1,102 @@
+package invasion
+
+import (
+	"sort"
+)
+
+// MapStats is a summary of a world map.
+type MapStats struct {
+	Cities int `json:"cities"`
+	Roads  int `json:"roads"`
+	// DegreeHistogram has the number of cities with 0 to 4 neighbours.
+	DegreeHistogram [5]int `json:"degree_histogram"`
+	// DeadEnds is the number of cities with a single neighbour.
+	DeadEnds int `json:"dead_ends"`
+	// Components has the sizes of the connected components, sorted from
+	// the biggest to the smallest.
+	Components []int `json:"components"`
+	// Diameter is the diameter of the map, see WorldMap.Diameter. It is nil
+	// if the stats were computed with WithoutDiameter.
+	Diameter *int `json:"diameter,omitempty"`
+	// Embeddable reports whether every component can be embedded in the
+	// plane, see WorldMap.Layout.
+	Embeddable bool `json:"embeddable"`
+	// BoundingBox is the bounding box of the cities with coordinates in
+	// the inferred layout. It is nil if no city has coordinates.
+	BoundingBox *BoundingBox `json:"bounding_box,omitempty"`
+}
+
+// BoundingBox represents a rectangle of the layout, including both corners.
+type BoundingBox struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// Width returns the number of columns of the bounding box.
+//
+func (b BoundingBox) Width() int {
+	return b.Max.X - b.Min.X + 1
+}
+
+// Height returns the number of rows of the bounding box.
+//
+func (b BoundingBox) Height() int {
+	return b.Max.Y - b.Min.Y + 1
+}
+
+// StatsOption configures how the map stats are computed.
+type StatsOption func(*statsConfig)
+
+// WithoutDiameter skips the diameter, which needs a breadth-first search
+// from each city and is slow for huge maps.
+func WithoutDiameter() StatsOption {
+	return func(cfg *statsConfig) {
+		cfg.skipDiameter = true
+	}
+}
+
+// statsConfig holds the options used to compute the map stats.
+type statsConfig struct {
+	skipDiameter bool
+}
+
+// Stats computes a summary of the world map.
+//
+func (m *WorldMap) Stats(opts ...StatsOption) *MapStats {
+	cfg := statsConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &MapStats{Cities: len(m.cities), Roads: len(m.Roads())}
+	for _, c := range m.cities {
+		s.DegreeHistogram[len(c.surroundingCities())]++
+	}
+	s.DeadEnds = s.DegreeHistogram[1]
+
+	layout := m.Layout()
+	s.Embeddable = layout.Embedded()
+	s.Components = make([]int, 0, len(layout.Components))
+	for _, comp := range layout.Components {
+		s.Components = append(s.Components, len(comp.Cities))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(s.Components)))
+
+	first := true
+	for _, p := range layout.Coords {
+		if first {
+			s.BoundingBox = &BoundingBox{Min: p, Max: p}
+			first = false
+			continue
+		}
+		b := s.BoundingBox
+		b.Min = Point{X: minInt(b.Min.X, p.X), Y: minInt(b.Min.Y, p.Y)}
+		b.Max = Point{X: maxInt(b.Max.X, p.X), Y: maxInt(b.Max.Y, p.Y)}
+	}
+
+	if !cfg.skipDiameter {
+		d := m.Diameter()
+		s.Diameter = &d
+	}
+	return s
+}
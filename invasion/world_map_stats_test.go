@@ -0,0 +1,65 @@
+package invasion
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldMap_Stats(t *testing.T) {
+
+	t.Run("small map", func(t *testing.T) {
+		s := parseSmallMap(t).Stats()
+		diameter := 4
+		assert.Equal(t, &MapStats{
+			Cities:          9,
+			Roads:           12,
+			DegreeHistogram: [5]int{0, 0, 4, 4, 1},
+			Components:      []int{9},
+			Diameter:        &diameter,
+			Embeddable:      true,
+			BoundingBox:     &BoundingBox{Min: Point{0, 0}, Max: Point{2, 2}},
+		}, s)
+		assert.Equal(t, 3, s.BoundingBox.Width())
+		assert.Equal(t, 3, s.BoundingBox.Height())
+	})
+
+	t.Run("many components", func(t *testing.T) {
+		wm, err := parseMapString("A east=B\nB south=C\nC west=D\nD south=A\nE east=F\nF east=G\nH\n")
+		require.NoError(t, err)
+		s := wm.Stats(WithoutDiameter())
+		assert.Equal(t, 8, s.Cities)
+		assert.Equal(t, 6, s.Roads)
+		assert.Equal(t, [5]int{1, 2, 5, 0, 0}, s.DegreeHistogram)
+		assert.Equal(t, 2, s.DeadEnds)
+		assert.Equal(t, []int{4, 3, 1}, s.Components)
+		assert.Nil(t, s.Diameter)
+		assert.False(t, s.Embeddable)
+		// E F G and H are embedded side by side
+		assert.Equal(t, &BoundingBox{Min: Point{0, 0}, Max: Point{4, 0}}, s.BoundingBox)
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		s := NewWorldMap().Stats()
+		assert.Equal(t, 0, s.Cities)
+		assert.Empty(t, s.Components)
+		assert.True(t, s.Embeddable)
+		assert.Nil(t, s.BoundingBox)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(parseSmallMap(t).Stats(WithoutDiameter()))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"cities": 9,
+			"roads": 12,
+			"degree_histogram": [0, 0, 4, 4, 1],
+			"dead_ends": 0,
+			"components": [9],
+			"embeddable": true,
+			"bounding_box": {"min": {"x": 0, "y": 0}, "max": {"x": 2, "y": 2}}
+		}`, string(data))
+	})
+}
@@ -28,6 +28,16 @@ func TestWorldMap_ParseWorldMap(t *testing.T) {
 		assert.Len(t, wm.cities, 9)
 	})
 
+	t.Run("comments and CRLF line endings", func(t *testing.T) {
+		f := openTestdataFile(t, "commented_map.txt")
+		defer f.Close()
+		wm, err := ParseWorldMap(bufio.NewScanner(f))
+		assert.NoError(t, err)
+		assert.Len(t, wm.cities, 9)
+		assert.Equal(t, []string{" Small map used by the comment tests.", "   Drawn by hand."}, wm.header)
+		assert.Equal(t, parseSmallMap(t).cities, wm.cities)
+	})
+
 	t.Run("inconsistent map error", func(t *testing.T) {
 		f := openTestdataFile(t, "inconsistent_map.txt")
 		defer f.Close()
@@ -36,6 +46,12 @@ func TestWorldMap_ParseWorldMap(t *testing.T) {
 		assert.EqualError(t, err, "Cannot parse the map: inconsistent map")
 	})
 
+	t.Run("city connected to itself", func(t *testing.T) {
+		wm, err := parseMapString("C1 east=C2\nC2 north=C2\n")
+		assert.Nil(t, wm)
+		assert.EqualError(t, err, "Cannot parse the map: city C2 is connected to itself at its north")
+	})
+
 	t.Run("success small map", func(t *testing.T) {
 		f := openTestdataFile(t, "small_map.txt")
 		defer f.Close()
@@ -52,47 +68,47 @@ func TestWorldMap_ParseWorldMap(t *testing.T) {
 
 		assert.Equal(t, &city{
 			name: "C1",
-			dirs: [4]*city{dirNorth: nil, dirSouth: cs[4], dirEast: cs[2], dirWest: nil},
+			dirs: [4]*city{North: nil, South: cs[4], East: cs[2], West: nil},
 		}, cs[1])
 
 		assert.Equal(t, &city{
 			name: "C2",
-			dirs: [4]*city{dirNorth: nil, dirSouth: cs[5], dirEast: cs[3], dirWest: cs[1]},
+			dirs: [4]*city{North: nil, South: cs[5], East: cs[3], West: cs[1]},
 		}, cs[2])
 
 		assert.Equal(t, &city{
 			name: "C3",
-			dirs: [4]*city{dirNorth: nil, dirSouth: cs[6], dirEast: nil, dirWest: cs[2]},
+			dirs: [4]*city{North: nil, South: cs[6], East: nil, West: cs[2]},
 		}, cs[3])
 
 		assert.Equal(t, &city{
 			name: "C4",
-			dirs: [4]*city{dirNorth: cs[1], dirSouth: cs[7], dirEast: cs[5], dirWest: nil},
+			dirs: [4]*city{North: cs[1], South: cs[7], East: cs[5], West: nil},
 		}, cs[4])
 
 		assert.Equal(t, &city{
 			name: "C5",
-			dirs: [4]*city{dirNorth: cs[2], dirSouth: cs[8], dirEast: cs[6], dirWest: cs[4]},
+			dirs: [4]*city{North: cs[2], South: cs[8], East: cs[6], West: cs[4]},
 		}, cs[5])
 
 		assert.Equal(t, &city{
 			name: "C6",
-			dirs: [4]*city{dirNorth: cs[3], dirSouth: cs[9], dirEast: nil, dirWest: cs[5]},
+			dirs: [4]*city{North: cs[3], South: cs[9], East: nil, West: cs[5]},
 		}, cs[6])
 
 		assert.Equal(t, &city{
 			name: "C7",
-			dirs: [4]*city{dirNorth: cs[4], dirSouth: nil, dirEast: cs[8], dirWest: nil},
+			dirs: [4]*city{North: cs[4], South: nil, East: cs[8], West: nil},
 		}, cs[7])
 
 		assert.Equal(t, &city{
 			name: "C8",
-			dirs: [4]*city{dirNorth: cs[5], dirSouth: nil, dirEast: cs[9], dirWest: cs[7]},
+			dirs: [4]*city{North: cs[5], South: nil, East: cs[9], West: cs[7]},
 		}, cs[8])
 
 		assert.Equal(t, &city{
 			name: "C9",
-			dirs: [4]*city{dirNorth: cs[6], dirSouth: nil, dirEast: nil, dirWest: cs[8]},
+			dirs: [4]*city{North: cs[6], South: nil, East: nil, West: cs[8]},
 		}, cs[9])
 	})
 
@@ -106,18 +122,18 @@ func TestWorldMap_ParseWorldMap(t *testing.T) {
 		// C119433 north=C119033 south=C119833 east=C119434 west=C119432
 		ctest := wm.cities["C119433"]
 		assert.Equal(t, "C119433", ctest.name)
-		assert.Equal(t, "C119033", ctest.dirs[dirNorth].name)
-		assert.Equal(t, "C119833", ctest.dirs[dirSouth].name)
-		assert.Equal(t, "C119434", ctest.dirs[dirEast].name)
-		assert.Equal(t, "C119432", ctest.dirs[dirWest].name)
+		assert.Equal(t, "C119033", ctest.dirs[North].name)
+		assert.Equal(t, "C119833", ctest.dirs[South].name)
+		assert.Equal(t, "C119434", ctest.dirs[East].name)
+		assert.Equal(t, "C119432", ctest.dirs[West].name)
 
 		// C119601 north=C119201 east=C119602
 		ctest = wm.cities["C119601"]
 		assert.Equal(t, "C119601", ctest.name)
-		assert.Equal(t, "C119201", ctest.dirs[dirNorth].name)
-		assert.Nil(t, ctest.dirs[dirSouth])
-		assert.Equal(t, "C119602", ctest.dirs[dirEast].name)
-		assert.Nil(t, ctest.dirs[dirWest])
+		assert.Equal(t, "C119201", ctest.dirs[North].name)
+		assert.Nil(t, ctest.dirs[South])
+		assert.Equal(t, "C119602", ctest.dirs[East].name)
+		assert.Nil(t, ctest.dirs[West])
 	})
 }
 
@@ -176,10 +192,10 @@ func TestWorldMap_destroyCity(t *testing.T) {
 
 		// surrounding cities should update their directions that were pointed
 		// to C5.
-		assert.Nil(t, wm.cities["C4"].dirs[dirEast])
-		assert.Nil(t, wm.cities["C6"].dirs[dirWest])
-		assert.Nil(t, wm.cities["C8"].dirs[dirNorth])
-		assert.Nil(t, wm.cities["C2"].dirs[dirSouth])
+		assert.Nil(t, wm.cities["C4"].dirs[East])
+		assert.Nil(t, wm.cities["C6"].dirs[West])
+		assert.Nil(t, wm.cities["C8"].dirs[North])
+		assert.Nil(t, wm.cities["C2"].dirs[South])
 	})
 
 	t.Run("destroy an existing city that has 3 surrounding cities", func(t *testing.T) {
@@ -196,9 +212,9 @@ func TestWorldMap_destroyCity(t *testing.T) {
 
 		// surrounding cities should update their directions that were pointed
 		// to C4.
-		assert.Nil(t, wm.cities["C1"].dirs[dirSouth])
-		assert.Nil(t, wm.cities["C5"].dirs[dirWest])
-		assert.Nil(t, wm.cities["C7"].dirs[dirNorth])
+		assert.Nil(t, wm.cities["C1"].dirs[South])
+		assert.Nil(t, wm.cities["C5"].dirs[West])
+		assert.Nil(t, wm.cities["C7"].dirs[North])
 	})
 
 	t.Run("destroy an existing city that has 2 surrounding cities", func(t *testing.T) {
@@ -215,8 +231,8 @@ func TestWorldMap_destroyCity(t *testing.T) {
 
 		// surrounding cities should update their directions that were pointed
 		// to C1.
-		assert.Nil(t, wm.cities["C2"].dirs[dirWest])
-		assert.Nil(t, wm.cities["C4"].dirs[dirNorth])
+		assert.Nil(t, wm.cities["C2"].dirs[West])
+		assert.Nil(t, wm.cities["C4"].dirs[North])
 	})
 
 }
@@ -251,6 +267,41 @@ C9 north=C6 west=C8
 	})
 }
 
+func TestWorldMap_WriteWorldMap(t *testing.T) {
+
+	t.Run("round trip keeps the header comments", func(t *testing.T) {
+		f := openTestdataFile(t, "commented_map.txt")
+		defer f.Close()
+		wm, err := ParseWorldMap(bufio.NewScanner(f))
+		assert.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteWorldMap(buf, wm))
+		assert.Equal(t, `# Small map used by the comment tests.
+#   Drawn by hand.
+C1 south=C4 east=C2
+C2 south=C5 east=C3 west=C1
+C3 south=C6 west=C2
+C4 north=C1 south=C7 east=C5
+C5 north=C2 south=C8 east=C6 west=C4
+C6 north=C3 south=C9 west=C5
+C7 north=C4 east=C8
+C8 north=C5 east=C9 west=C7
+C9 north=C6 west=C8
+`, buf.String())
+
+		wm2, err := ParseWorldMap(bufio.NewScanner(buf))
+		assert.NoError(t, err)
+		assert.Equal(t, wm, wm2)
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, WriteWorldMap(buf, &WorldMap{cities: map[string]*city{}}))
+		assert.Empty(t, buf.String())
+	})
+}
+
 func TestWorldMap_decodeMapLine(t *testing.T) {
 	t.Run("invalid format wrong number of spaces", func(t *testing.T) {
 		d, err := decodeMapLine("My city north=N south=S east=E west=W")
@@ -282,25 +333,65 @@ func TestWorldMap_decodeMapLine(t *testing.T) {
 		assert.EqualError(t, err, "Invalid map line: multiple south directions")
 	})
 
+	t.Run("missing city name", func(t *testing.T) {
+		d, err := decodeMapLine("north=N # C1")
+		assert.Nil(t, d)
+		assert.EqualError(t, err, "Invalid map line: missing city name")
+	})
+
+	t.Run("success with a trailing comment", func(t *testing.T) {
+		d, err := decodeMapLine("C1 north=C2 # west=C3\r")
+		assert.NoError(t, err)
+		assert.Equal(t, &rawCityData{name: "C1", dirs: [4]string{North: "C2"}}, d)
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		d, err := decodeMapLine("C1 north=C2 @population")
+		assert.Nil(t, d)
+		assert.EqualError(t, err, "Invalid map line: attribute @population without value")
+
+		d, err = decodeMapLine("C1 north=C2 @=3")
+		assert.Nil(t, d)
+		assert.EqualError(t, err, "Invalid map line: empty attribute name")
+
+		d, err = decodeMapLine("C1 north=C2 @pop/ulation=3")
+		assert.Nil(t, d)
+		assert.EqualError(t, err, "Invalid map line: @pop/ulation is not a valid attribute name")
+
+		d, err = decodeMapLine("C1 @defense=3 north=C2 @defense=4")
+		assert.Nil(t, d)
+		assert.EqualError(t, err, "Invalid map line: multiple @defense attributes")
+	})
+
+	t.Run("success with attributes", func(t *testing.T) {
+		d, err := decodeMapLine("C5 north=C2 @population=12000 south=C8 east=C6 west=C4 @terrain=mountain")
+		assert.NoError(t, err)
+		assert.Equal(t, &rawCityData{
+			name:  "C5",
+			dirs:  [4]string{North: "C2", South: "C8", East: "C6", West: "C4"},
+			attrs: map[string]string{"population": "12000", "terrain": "mountain"},
+		}, d)
+	})
+
 	t.Run("success with all directions", func(t *testing.T) {
 		d, err := decodeMapLine("C1 west=C5 south=C3 east=C4 north=C2")
 		assert.NoError(t, err)
 		assert.NotNil(t, d)
 		assert.Equal(t, "C1", d.name)
-		assert.Equal(t, "C2", d.dirs[dirNorth])
-		assert.Equal(t, "C3", d.dirs[dirSouth])
-		assert.Equal(t, "C4", d.dirs[dirEast])
-		assert.Equal(t, "C5", d.dirs[dirWest])
+		assert.Equal(t, "C2", d.dirs[North])
+		assert.Equal(t, "C3", d.dirs[South])
+		assert.Equal(t, "C4", d.dirs[East])
+		assert.Equal(t, "C5", d.dirs[West])
 	})
 
 	t.Run("success with incomplete directions", func(t *testing.T) {
 		d, err := decodeMapLine("C1 east=C2 north=C3")
 		assert.NoError(t, err)
 		assert.Equal(t, "C1", d.name)
-		assert.Equal(t, "C3", d.dirs[dirNorth])
-		assert.Equal(t, "", d.dirs[dirSouth])
-		assert.Equal(t, "C2", d.dirs[dirEast])
-		assert.Equal(t, "", d.dirs[dirWest])
+		assert.Equal(t, "C3", d.dirs[North])
+		assert.Equal(t, "", d.dirs[South])
+		assert.Equal(t, "C2", d.dirs[East])
+		assert.Equal(t, "", d.dirs[West])
 	})
 }
 
@@ -0,0 +1,269 @@
+package mapgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// grid holds the cities and roads of a generated map. Cities are placed on
+// the cells of a grid and named C1, C2... row by row, and roads join
+// neighbouring cells or the edges of the grid, depending on the wrap. All
+// the generators write their maps through it.
+type grid struct {
+	width, height int
+	wrap          Wrap
+
+	// removedCities, removedEast and removedSouth mark the cities and roads
+	// (by the city at their west or north) removed from the grid. They are
+	// nil for a complete grid.
+	removedCities []bool
+	removedEast   []bool
+	removedSouth  []bool
+}
+
+// newEmptyGrid creates a grid without cities nor roads, so the generators
+// can add them.
+func newEmptyGrid(width, height int) *grid {
+	n := width * height
+	g := &grid{
+		width:         width,
+		height:        height,
+		removedCities: make([]bool, n),
+		removedEast:   make([]bool, n),
+		removedSouth:  make([]bool, n),
+	}
+	for k := 0; k < n; k++ {
+		g.removedCities[k], g.removedEast[k], g.removedSouth[k] = true, true, true
+	}
+	return g
+}
+
+// addCity adds the city at the given cell.
+//
+func (g *grid) addCity(i, j int) {
+	g.removedCities[j*g.width+i] = false
+}
+
+// addRoad adds the road at the given direction of the city at a cell,
+// together with the cities at both ends.
+func (g *grid) addRoad(i, j, dir int) {
+	k := j*g.width + i
+	r, ok := g.cityRoad(k, dir)
+	if !ok {
+		return
+	}
+	a, b := g.roadEnds(r)
+	g.removedCities[a], g.removedCities[b] = false, false
+	g.setRoadRemoved(r, false)
+}
+
+// connect adds the road between two neighbouring cells, together with the
+// cities at both ends.
+func (g *grid) connect(i1, j1, i2, j2 int) {
+	switch {
+	case i2 == i1 && j2 == j1-1:
+		g.addRoad(i1, j1, north)
+	case i2 == i1 && j2 == j1+1:
+		g.addRoad(i1, j1, south)
+	case j2 == j1 && i2 == i1+1:
+		g.addRoad(i1, j1, east)
+	case j2 == j1 && i2 == i1-1:
+		g.addRoad(i1, j1, west)
+	}
+}
+
+// write writes the cities of the grid, one per line and row by row.
+//
+func (g *grid) write() []byte {
+	buf := &bytes.Buffer{}
+	buf.Grow(g.width * g.height * 40)
+
+	for j := 0; j < g.height; j++ {
+		for i := 0; i < g.width; i++ {
+			if g.removedCities != nil && g.removedCities[j*g.width+i] {
+				continue
+			}
+			buf.WriteString(g.createCityLine(i, j))
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// createCityLine creates a formatted city line in the form:
+// <city> north=<north_city> south=<south_city> east=<east_city> west=<west_city>
+func (g *grid) createCityLine(i, j int) string {
+	k := j*g.width + i
+
+	sb := strings.Builder{}
+	sb.Grow(40)
+
+	sb.WriteString(g.getCityName(i, j))
+	for dir, name := range dirNames {
+		r, ok := g.cityRoad(k, dir)
+		if !ok || !g.hasRoad(r) {
+			continue
+		}
+		o := g.otherEnd(r, k)
+		sb.WriteByte(' ')
+		sb.WriteString(fmt.Sprintf("%s=%s", name, g.getCityName(o%g.width, o/g.width)))
+	}
+	return sb.String()
+}
+
+// getCityName returns the city name based on the map coordinates.
+//
+func (g *grid) getCityName(i, j int) string {
+	return fmt.Sprintf("C%d", j*g.width+i+1)
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// grid directions, in the order used to write the city lines
+const (
+	north = iota
+	south
+	east
+	west
+)
+
+// dirNames has the names of the grid directions.
+//
+var dirNames = [...]string{"north", "south", "east", "west"}
+
+// gridRoads returns the roads of the complete grid. A road is identified
+// by the number of the city at its west or north multiplied by 2, plus 1
+// for the roads going south. The roads joining the edges of the grid are
+// identified by the city at the east or south edge.
+func (g *grid) gridRoads() []int {
+	roads := make([]int, 0, 2*g.width*g.height)
+	for k := 0; k < g.width*g.height; k++ {
+		if r, ok := g.cityRoad(k, east); ok {
+			roads = append(roads, r)
+		}
+		if r, ok := g.cityRoad(k, south); ok {
+			roads = append(roads, r)
+		}
+	}
+	return roads
+}
+
+// cityRoad returns the road at the given direction of a city, if the grid
+// has one.
+func (g *grid) cityRoad(k, dir int) (int, bool) {
+	i, j := k%g.width, k/g.width
+	switch dir {
+	case north:
+		if j > 0 {
+			return 2*(k-g.width) + 1, true
+		}
+		if g.wrapsNorthSouth() {
+			return 2*((g.height-1)*g.width+i) + 1, true
+		}
+	case south:
+		if j+1 < g.height || g.wrapsNorthSouth() {
+			return 2*k + 1, true
+		}
+	case east:
+		if i+1 < g.width || g.wrapsEastWest() {
+			return 2 * k, true
+		}
+	case west:
+		if i > 0 {
+			return 2 * (k - 1), true
+		}
+		if g.wrapsEastWest() {
+			return 2 * (g.wrappedRow(j)*g.width + g.width - 1), true
+		}
+	}
+	return 0, false
+}
+
+// cityRoads returns the roads of the complete grid that reach a city, in
+// the order north, south, east and west.
+func (g *grid) cityRoads(k int) []int {
+	roads := make([]int, 0, 4)
+	for dir := range dirNames {
+		if r, ok := g.cityRoad(k, dir); ok {
+			roads = append(roads, r)
+		}
+	}
+	return roads
+}
+
+// roadEnds returns the cities at both ends of a road: the city at its west
+// or north first.
+func (g *grid) roadEnds(r int) (int, int) {
+	k := r / 2
+	i, j := k%g.width, k/g.width
+	if r%2 == 0 {
+		if i+1 < g.width {
+			return k, k + 1
+		}
+		return k, g.wrappedRow(j) * g.width
+	}
+	if j+1 < g.height {
+		return k, k + g.width
+	}
+	return k, i
+}
+
+// otherEnd returns the city at the other end of a road.
+//
+func (g *grid) otherEnd(r, k int) int {
+	a, b := g.roadEnds(r)
+	if a == k {
+		return b
+	}
+	return a
+}
+
+// roadRemoved reports whether a road was removed.
+//
+func (g *grid) roadRemoved(r int) bool {
+	if r%2 == 0 {
+		return g.removedEast[r/2]
+	}
+	return g.removedSouth[r/2]
+}
+
+// setRoadRemoved marks a road as removed or not.
+//
+func (g *grid) setRoadRemoved(r int, removed bool) {
+	if r%2 == 0 {
+		g.removedEast[r/2] = removed
+	} else {
+		g.removedSouth[r/2] = removed
+	}
+}
+
+// hasRoad reports whether a road of the grid was kept.
+//
+func (g *grid) hasRoad(r int) bool {
+	return g.removedEast == nil || !g.roadRemoved(r)
+}
+
+// wrapsEastWest reports whether the east edge of the grid is joined to the
+// west edge.
+func (g *grid) wrapsEastWest() bool {
+	return g.wrap != NoWrap && g.width >= 3
+}
+
+// wrapsNorthSouth reports whether the south edge of the grid is joined to
+// the north edge.
+func (g *grid) wrapsNorthSouth() bool {
+	return g.wrap == WrapTorus && g.height >= 3
+}
+
+// wrappedRow returns the row joined to the given one by the east-west wrap.
+//
+func (g *grid) wrappedRow(j int) int {
+	if g.wrap == WrapMobius {
+		return g.height - 1 - j
+	}
+	return j
+}
@@ -0,0 +1,83 @@
+package mapgen
+
+import (
+	"math/rand"
+)
+
+// WithArmLength sets the number of cities of each arm of the hubs of
+// HubGenerator. The default is 2.
+func WithArmLength(n int) Option {
+	return func(o *options) {
+		o.armLength = n
+		o.use("arm-length")
+	}
+}
+
+// WithHubLinks joins each pair of neighbouring hubs of HubGenerator with
+// the given probability, between 0 and 1. The default is 1, so all the
+// hubs are joined; with 0 every hub is an isolated star.
+func WithHubLinks(p float64) Option {
+	return func(o *options) {
+		o.hubLinks = p
+		o.use("hub-links")
+	}
+}
+
+// HubGenerator generates maps with hubs: cities with four arms, one at each
+// direction, shaped like a cross. The hubs are placed in rows and columns
+// filling the grid, and the arms of neighbouring hubs can be joined by a
+// city between them. It uses the options WithSeed, WithArmLength and
+// WithHubLinks.
+type HubGenerator struct {
+	width, height int
+	opts          options
+}
+
+// NewHubGenerator creates a new hub map generator instance.
+//
+func NewHubGenerator(width, height int, opts ...Option) *HubGenerator {
+	return &HubGenerator{width: width, height: height, opts: *newOptions(opts)}
+}
+
+// Generate generates a world map with hubs. If the grid is smaller than a
+// hub, the map is empty.
+func (g *HubGenerator) Generate() []byte {
+	gr := newEmptyGrid(g.width, g.height)
+	arm := g.opts.armLength
+	if arm < 1 {
+		arm = 1
+	}
+	spacing := 2*arm + 2
+
+	// hub centers
+	var xs, ys []int
+	for x := arm; x+arm < g.width; x += spacing {
+		xs = append(xs, x)
+	}
+	for y := arm; y+arm < g.height; y += spacing {
+		ys = append(ys, y)
+	}
+
+	rnd := rand.New(rand.NewSource(g.opts.seed))
+	for b, y := range ys {
+		for a, x := range xs {
+			gr.addCity(x, y)
+			for d := 1; d <= arm; d++ {
+				gr.connect(x, y-d+1, x, y-d)
+				gr.connect(x, y+d-1, x, y+d)
+				gr.connect(x+d-1, y, x+d, y)
+				gr.connect(x-d+1, y, x-d, y)
+			}
+			// join the arms of the hubs at the east and south
+			if a+1 < len(xs) && rnd.Float64() < g.opts.hubLinks {
+				gr.connect(x+arm, y, x+arm+1, y)
+				gr.connect(x+arm+1, y, x+arm+2, y)
+			}
+			if b+1 < len(ys) && rnd.Float64() < g.opts.hubLinks {
+				gr.connect(x, y+arm, x, y+arm+1)
+				gr.connect(x, y+arm+1, x, y+arm+2)
+			}
+		}
+	}
+	return gr.write()
+}
@@ -0,0 +1,42 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubs_Generate(t *testing.T) {
+	g := NewHubGenerator(3, 3, WithArmLength(1))
+	assert.Equal(t, `C2 south=C5
+C4 east=C5
+C5 north=C2 south=C8 east=C6 west=C4
+C6 west=C5
+C8 north=C5
+`, string(g.Generate()))
+
+	// too small for a hub
+	assert.Empty(t, NewHubGenerator(4, 4).Generate())
+}
+
+func TestHubs_Links(t *testing.T) {
+	// 3x2 hubs with arms of 2 cities, all joined
+	wm := parseMap(t, NewHubGenerator(18, 12).Generate())
+	require.NoError(t, wm.Validate())
+	stats := wm.Stats(invasion.WithoutDiameter())
+	assert.Equal(t, []int{wm.NumCities()}, stats.Components)
+	assert.Equal(t, 6, stats.DegreeHistogram[4])
+	assert.Equal(t, "C43", wm.Neighbours("C42")[invasion.East])
+
+	// isolated stars
+	wm = parseMap(t, NewHubGenerator(18, 12, WithHubLinks(0)).Generate())
+	assert.Equal(t, 6*9, wm.NumCities())
+	assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 6)
+
+	// some of the links
+	data := NewHubGenerator(18, 12, WithSeed(2), WithHubLinks(0.5)).Generate()
+	assert.Equal(t, data, NewHubGenerator(18, 12, WithSeed(2), WithHubLinks(0.5)).Generate())
+	require.NoError(t, parseMap(t, data).Validate())
+}
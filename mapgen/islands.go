@@ -0,0 +1,131 @@
+package mapgen
+
+import (
+	"math"
+	"math/rand"
+)
+
+// WithIslands sets the number of islands generated by IslandsGenerator. The
+// default is 3.
+func WithIslands(n int) Option {
+	return func(o *options) {
+		o.islands = n
+		o.use("islands")
+	}
+}
+
+// WithLandRatio sets the ratio of cells of the grid covered by the islands
+// of IslandsGenerator, between 0 and 1. The default is 0.4.
+func WithLandRatio(r float64) Option {
+	return func(o *options) {
+		o.landRatio = r
+		o.use("land-ratio")
+	}
+}
+
+// IslandsGenerator generates maps with several islands: parts of the grid
+// that grow randomly from a few cells, always separated by at least one
+// cell without city, so there are no roads between them. It uses the
+// options WithSeed, WithIslands and WithLandRatio, and the GridGenerator
+// options to change the islands, except WithWrap.
+type IslandsGenerator struct {
+	width, height int
+	opts          options
+}
+
+// NewIslandsGenerator creates a new islands map generator instance.
+//
+func NewIslandsGenerator(width, height int, opts ...Option) *IslandsGenerator {
+	return &IslandsGenerator{width: width, height: height, opts: *newOptions(opts)}
+}
+
+// Generate generates a world map with islands.
+//
+func (g *IslandsGenerator) Generate() []byte {
+	opts := g.opts
+	opts.wrap = NoWrap
+	gg := &GridGenerator{grid: grid{width: g.width, height: g.height}, opts: opts}
+	gg.cells = g.islandCells(rand.New(rand.NewSource(g.opts.seed)))
+	return gg.Generate()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// islandCells returns the cells of the grid covered by the islands.
+//
+func (g *IslandsGenerator) islandCells(rnd *rand.Rand) []bool {
+	n := g.width * g.height
+	owner := make([]int, n)
+	for k := range owner {
+		owner[k] = -1
+	}
+	land := newEmptyGrid(g.width, g.height)
+	neighbours := func(k int) []int {
+		ns := make([]int, 0, 4)
+		for _, r := range land.cityRoads(k) {
+			ns = append(ns, land.otherEnd(r, k))
+		}
+		return ns
+	}
+	// a cell can join an island if it does not touch another one
+	free := func(k, island int) bool {
+		if owner[k] != -1 {
+			return false
+		}
+		for _, o := range neighbours(k) {
+			if owner[o] != -1 && owner[o] != island {
+				return false
+			}
+		}
+		return true
+	}
+
+	// the first cell of each island
+	var frontiers [][]int
+	covered := 0
+	for _, k := range rnd.Perm(n) {
+		if len(frontiers) == g.opts.islands {
+			break
+		}
+		if !free(k, len(frontiers)) {
+			continue
+		}
+		owner[k] = len(frontiers)
+		frontiers = append(frontiers, neighbours(k))
+		covered++
+	}
+
+	target := int(math.Round(g.opts.landRatio * float64(n)))
+	for covered < target {
+		// grow a random island that still has room
+		var growing []int
+		for i, f := range frontiers {
+			if len(f) > 0 {
+				growing = append(growing, i)
+			}
+		}
+		if len(growing) == 0 {
+			break
+		}
+		island := growing[rnd.Intn(len(growing))]
+		f := frontiers[island]
+		i := rnd.Intn(len(f))
+		k := f[i]
+		f[i] = f[len(f)-1]
+		frontiers[island] = f[:len(f)-1]
+		if !free(k, island) {
+			continue
+		}
+		owner[k] = island
+		frontiers[island] = append(frontiers[island], neighbours(k)...)
+		covered++
+	}
+
+	cells := make([]bool, n)
+	for k, o := range owner {
+		cells[k] = o != -1
+	}
+	return cells
+}
@@ -0,0 +1,35 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIslands_Generate(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		data := NewIslandsGenerator(20, 15, WithSeed(seed), WithIslands(4), WithLandRatio(0.3)).Generate()
+		assert.Equal(t, data, NewIslandsGenerator(20, 15, WithSeed(seed), WithIslands(4), WithLandRatio(0.3)).Generate())
+
+		wm := parseMap(t, data)
+		require.NoError(t, wm.Validate())
+		assert.InDelta(t, 0.3*20*15, wm.NumCities(), 1)
+		assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 4)
+	}
+
+	// a single island covering the whole grid
+	wm := parseMap(t, NewIslandsGenerator(6, 5, WithIslands(1), WithLandRatio(1)).Generate())
+	assert.Equal(t, parseMap(t, NewGridGenerator(6, 5).Generate()), wm)
+}
+
+func TestIslands_GridOptions(t *testing.T) {
+	opts := []Option{WithSeed(4), WithRoadRemoval(0.4), WithKeepConnected(), WithWrap(WrapTorus)}
+	wm := parseMap(t, NewIslandsGenerator(20, 15, opts...).Generate())
+	require.NoError(t, wm.Validate())
+	// each island stays connected, and the edges are not joined
+	assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 3)
+	_, ok := wm.Neighbours("C1")[invasion.West]
+	assert.False(t, ok)
+}
@@ -0,0 +1,65 @@
+package mapgen
+
+import (
+	"math/rand"
+)
+
+// WithBranches adds a city at the north and at the south of each city of
+// the line of LineGenerator, each one with the given probability, between
+// 0 and 1. The branches are dead ends.
+func WithBranches(p float64) Option {
+	return func(o *options) {
+		o.branches = p
+		o.use("branches")
+	}
+}
+
+// LineGenerator generates maps with cities in a row, from west to east. It
+// uses the options WithSeed and WithBranches. With branches, the line is
+// placed in the second row of a grid of 3 rows, so the names of its cities
+// start at C<length+1>.
+type LineGenerator struct {
+	length int
+	opts   options
+}
+
+// NewLineGenerator creates a new line map generator instance.
+//
+func NewLineGenerator(length int, opts ...Option) *LineGenerator {
+	return &LineGenerator{length: length, opts: *newOptions(opts)}
+}
+
+// Generate generates a world map with a line.
+//
+func (g *LineGenerator) Generate() []byte {
+	if g.opts.branches <= 0 {
+		gr := newEmptyGrid(g.length, 1)
+		g.addLine(gr, 0)
+		return gr.write()
+	}
+
+	gr := newEmptyGrid(g.length, 3)
+	g.addLine(gr, 1)
+	rnd := rand.New(rand.NewSource(g.opts.seed))
+	for i := 0; i < g.length; i++ {
+		if rnd.Float64() < g.opts.branches {
+			gr.connect(i, 1, i, 0)
+		}
+		if rnd.Float64() < g.opts.branches {
+			gr.connect(i, 1, i, 2)
+		}
+	}
+	return gr.write()
+}
+
+// addLine adds the cities of a row of the grid, joined by roads.
+//
+func (g *LineGenerator) addLine(gr *grid, j int) {
+	for i := 0; i < g.length; i++ {
+		if i == 0 {
+			gr.addCity(i, j)
+		} else {
+			gr.connect(i-1, j, i, j)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLine_Generate(t *testing.T) {
+	assert.Equal(t, `C1 east=C2
+C2 east=C3 west=C1
+C3 west=C2
+`, string(NewLineGenerator(3).Generate()))
+	assert.Equal(t, "C1\n", string(NewLineGenerator(1).Generate()))
+	assert.Empty(t, NewLineGenerator(0).Generate())
+}
+
+func TestLine_Branches(t *testing.T) {
+	data := NewLineGenerator(10, WithSeed(5), WithBranches(0.5)).Generate()
+	assert.Equal(t, data, NewLineGenerator(10, WithSeed(5), WithBranches(0.5)).Generate())
+
+	wm := parseMap(t, data)
+	require.NoError(t, wm.Validate())
+	assert.Greater(t, wm.NumCities(), 10)
+	assert.Less(t, wm.NumCities(), 30)
+	// the branches are dead ends of a tree
+	assert.Len(t, wm.Roads(), wm.NumCities()-1)
+	assert.Equal(t, "C12", wm.Neighbours("C11")[invasion.East])
+
+	// all the branches
+	wm = parseMap(t, NewLineGenerator(4, WithBranches(1)).Generate())
+	assert.Equal(t, 12, wm.NumCities())
+	assert.Equal(t, 8, wm.Stats().DeadEnds)
+}
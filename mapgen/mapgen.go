@@ -1,69 +1,182 @@
 package mapgen
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"strings"
 )
 
-// Generator represents map generator object.
-type Generator struct {
-	width, height int
+// Generator generates world maps in the text format, with one city per
+// line.
+type Generator interface {
+	// Generate generates a world map.
+	Generate() []byte
 }
 
-// NewGenerator creates a new map generator instance.
+// Option configures a map generator. Each generator only uses some of the
+// options, as explained in its documentation, and ignores the rest.
+type Option func(*options)
+
+// options holds the settings of all the generators.
 //
-func NewGenerator(width, height int) *Generator {
-	return &Generator{width: width, height: height}
+type options struct {
+	seed          int64
+	roadRemoval   float64
+	cityRemoval   float64
+	keepConnected bool
+	maze          MazeAlgorithm
+	loops         float64
+	wrap          Wrap
+	mask          []string
+	islands       int
+	landRatio     float64
+	crossLinks    float64
+	branches      float64
+	armLength     int
+	hubLinks      float64
+
+	// used has the names of the options that were set, in order, so the
+	// registry can reject the ones a generator type does not use.
+	used []string
 }
 
-// Generate generates a world map using the width and height as the number of citys at its borders.
+// use records that the option with the given name was set.
 //
-func (g *Generator) Generate() []byte {
-	buf := &bytes.Buffer{}
-	buf.Grow(g.width * g.height * 40)
-
-	for j := 0; j < g.height; j++ {
-		for i := 0; i < g.width; i++ {
-			buf.WriteString(g.createCityLine(i, j))
-			buf.WriteByte('\n')
+func (o *options) use(name string) {
+	for _, n := range o.used {
+		if n == name {
+			return
 		}
 	}
+	o.used = append(o.used, name)
+}
+
+// WithSeed sets the seed used by the random choices of the generators. The
+// same seed always generates the same map. The default seed is 0.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.use("seed")
+	}
+}
 
-	return buf.Bytes()
+// WithRoadRemoval removes each road of the grid with the given probability,
+// between 0 and 1.
+func WithRoadRemoval(p float64) Option {
+	return func(o *options) {
+		o.roadRemoval = p
+		o.use("road-removal")
+	}
 }
 
-// createCityLine creates a formatted city line in the form:
-// <city> north=<north_city> south=<south_city> east=<east_city> west=<west_city>
-func (g *Generator) createCityLine(i, j int) string {
-	north, south := j-1, j+1
-	east, west := i+1, i-1
+// WithCityRemoval removes each city of the grid, together with its roads,
+// with the given probability, between 0 and 1.
+func WithCityRemoval(p float64) Option {
+	return func(o *options) {
+		o.cityRemoval = p
+		o.use("city-removal")
+	}
+}
 
-	sb := strings.Builder{}
-	sb.Grow(40)
+// WithKeepConnected keeps the generated map connected: after removing roads
+// and cities randomly, some of them are restored so every city can be
+// reached from any other one.
+func WithKeepConnected() Option {
+	return func(o *options) {
+		o.keepConnected = true
+		o.use("keep-connected")
+	}
+}
 
-	sb.WriteString(g.getCityName(i, j))
-	if north >= 0 && north < g.height {
-		sb.WriteByte(' ')
-		sb.WriteString(fmt.Sprintf("north=%s", g.getCityName(i, north)))
+// CheckOptions checks the values of the options and that they can be used
+// together: the probabilities and the land ratio must be between 0 and 1,
+// the number of islands and the arm length at least 1, the loops need a
+// maze, and the road removal cannot be used with one. New calls it before
+// creating a generator; the generators created directly do not check their
+// options.
+func CheckOptions(opts ...Option) error {
+	o := newOptions(opts)
+	ratios := []struct {
+		name string
+		v    float64
+	}{
+		{"road removal probability", o.roadRemoval},
+		{"city removal probability", o.cityRemoval},
+		{"loop probability", o.loops},
+		{"land ratio", o.landRatio},
+		{"cross link probability", o.crossLinks},
+		{"branch probability", o.branches},
+		{"hub link probability", o.hubLinks},
 	}
-	if south >= 0 && south < g.height {
-		sb.WriteByte(' ')
-		sb.WriteString(fmt.Sprintf("south=%s", g.getCityName(i, south)))
+	for _, r := range ratios {
+		if r.v < 0 || r.v > 1 {
+			return fmt.Errorf("the %s must be between 0 and 1, got %v", r.name, r.v)
+		}
+	}
+	if o.islands < 1 {
+		return fmt.Errorf("the number of islands must be at least 1, got %d", o.islands)
 	}
-	if east >= 0 && east < g.width {
-		sb.WriteByte(' ')
-		sb.WriteString(fmt.Sprintf("east=%s", g.getCityName(east, j)))
+	if o.armLength < 1 {
+		return fmt.Errorf("the arm length must be at least 1, got %d", o.armLength)
 	}
-	if west >= 0 && west < g.width {
-		sb.WriteByte(' ')
-		sb.WriteString(fmt.Sprintf("west=%s", g.getCityName(west, j)))
+	if o.maze == NoMaze && o.loops > 0 {
+		return errors.New("the loop probability can only be used with a maze")
 	}
-	return sb.String()
+	if o.maze != NoMaze && o.roadRemoval > 0 {
+		return errors.New("the road removal cannot be used with a maze, the maze removes the roads")
+	}
+	return nil
 }
 
-// getCityName returns the city name based on the map coordinates.
+// GridGenerator generates maps from a rectangular grid of cities. Besides
+// the complete grid, it can remove roads and cities randomly, carve mazes
+// and join the edges of the grid. It uses the options WithSeed,
+// WithRoadRemoval, WithCityRemoval, WithKeepConnected, WithMaze, WithLoops
+// and WithWrap.
+type GridGenerator struct {
+	grid
+	opts options
+
+	// cells marks the cells of the grid that can have a city, or nil for
+	// all of them.
+	cells []bool
+}
+
+// NewGridGenerator creates a new grid map generator instance.
+//
+func NewGridGenerator(width, height int, opts ...Option) *GridGenerator {
+	o := newOptions(opts)
+	return &GridGenerator{grid: grid{width: width, height: height, wrap: o.wrap}, opts: *o}
+}
+
+// NewGenerator creates a new grid map generator instance.
 //
-func (g *Generator) getCityName(i, j int) string {
-	return fmt.Sprintf("C%d", j*g.width+i+1)
+// Deprecated: use NewGridGenerator, or New to choose the generator type.
+func NewGenerator(width, height int, opts ...Option) *GridGenerator {
+	return NewGridGenerator(width, height, opts...)
+}
+
+// Generate generates a world map using the width and height as the number of citys at its borders.
+//
+func (g *GridGenerator) Generate() []byte {
+	g.prune()
+	return g.write()
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// newOptions applies the options over the default settings.
+//
+func newOptions(opts []Option) *options {
+	o := &options{
+		islands:   3,
+		landRatio: 0.4,
+		armLength: 2,
+		hubLinks:  1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
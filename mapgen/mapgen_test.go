@@ -9,7 +9,7 @@ import (
 
 func TestCityName_Uniqueness(t *testing.T) {
 
-	g := Generator{width: 20, height: 20}
+	g := NewGenerator(20, 20)
 
 	cityNames := make(map[string]struct{}, g.width*g.height)
 
@@ -27,7 +27,7 @@ func TestCityName_Uniqueness(t *testing.T) {
 }
 
 func TestCityLine_Create(t *testing.T) {
-	g := Generator{width: 8, height: 5}
+	g := NewGenerator(8, 5)
 
 	cl := g.createCityLine(2, 1)
 	assert.Equal(t, "C11 north=C3 south=C19 east=C12 west=C10", cl)
@@ -52,7 +52,7 @@ func TestCityLine_Create(t *testing.T) {
 }
 
 func TestMapGeneration(t *testing.T) {
-	g := Generator{width: 3, height: 3}
+	g := NewGenerator(3, 3)
 
 	data := g.Generate()
 
@@ -68,3 +68,22 @@ C9 north=C6 west=C8
 `, string(data))
 
 }
+
+func TestCheckOptions(t *testing.T) {
+	assert.NoError(t, CheckOptions())
+	assert.NoError(t, CheckOptions(WithRoadRemoval(0.3), WithCityRemoval(0.1), WithKeepConnected()))
+	assert.NoError(t, CheckOptions(WithMaze(MazePrim), WithLoops(0.1), WithCityRemoval(0.1)))
+
+	assert.EqualError(t, CheckOptions(WithRoadRemoval(1.5)), "the road removal probability must be between 0 and 1, got 1.5")
+	assert.EqualError(t, CheckOptions(WithMaze(MazePrim), WithLoops(-1)), "the loop probability must be between 0 and 1, got -1")
+	assert.EqualError(t, CheckOptions(WithLoops(0.1)), "the loop probability can only be used with a maze")
+	assert.EqualError(t, CheckOptions(WithMaze(MazeKruskal), WithRoadRemoval(0.3)), "the road removal cannot be used with a maze, the maze removes the roads")
+
+	assert.NoError(t, CheckOptions(WithIslands(1), WithLandRatio(1), WithCrossLinks(0), WithBranches(1), WithArmLength(1), WithHubLinks(0)))
+	assert.EqualError(t, CheckOptions(WithLandRatio(1.2)), "the land ratio must be between 0 and 1, got 1.2")
+	assert.EqualError(t, CheckOptions(WithCrossLinks(-0.5)), "the cross link probability must be between 0 and 1, got -0.5")
+	assert.EqualError(t, CheckOptions(WithBranches(2)), "the branch probability must be between 0 and 1, got 2")
+	assert.EqualError(t, CheckOptions(WithHubLinks(-1)), "the hub link probability must be between 0 and 1, got -1")
+	assert.EqualError(t, CheckOptions(WithIslands(0)), "the number of islands must be at least 1, got 0")
+	assert.EqualError(t, CheckOptions(WithArmLength(-2)), "the arm length must be at least 1, got -2")
+}
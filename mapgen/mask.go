@@ -0,0 +1,61 @@
+package mapgen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithMask sets the mask used by NewMaskGenerator: one string per row of
+// the grid, with one character per cell. Cells with '.' or ' ' have no
+// city, and so do the cells missing at the end of the shorter rows.
+func WithMask(rows []string) Option {
+	return func(o *options) {
+		o.mask = rows
+		o.use("mask")
+	}
+}
+
+// NewMaskGenerator creates a grid generator whose cities are placed by the
+// mask set with WithMask. The grid is as wide as the longest row of the
+// mask, and roads join the neighbouring cities. Like GridGenerator, roads
+// and cities can be removed, mazes carved and edges joined; the cells
+// without cities are never restored, so WithKeepConnected keeps each part
+// of the mask connected.
+func NewMaskGenerator(opts ...Option) *GridGenerator {
+	o := newOptions(opts)
+	width := 0
+	for _, row := range o.mask {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	height := len(o.mask)
+
+	g := &GridGenerator{grid: grid{width: width, height: height, wrap: o.wrap}, opts: *o}
+	g.cells = make([]bool, width*height)
+	for j, row := range o.mask {
+		for i := 0; i < len(row); i++ {
+			g.cells[j*width+i] = row[i] != '.' && row[i] != ' '
+		}
+	}
+	return g
+}
+
+// ReadMask reads a mask for WithMask, one row per line. Empty lines at the
+// end are ignored.
+func ReadMask(r io.Reader) ([]string, error) {
+	var rows []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		rows = append(rows, strings.TrimRight(s.Text(), "\r"))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Cannot read the mask: %w", err)
+	}
+	for len(rows) > 0 && strings.TrimSpace(rows[len(rows)-1]) == "" {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
@@ -0,0 +1,52 @@
+package mapgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMask_Generate(t *testing.T) {
+	g := NewMaskGenerator(WithMask([]string{
+		"##.#",
+		"# ##",
+		"#",
+	}))
+	assert.Equal(t, `C1 south=C5 east=C2
+C2 west=C1
+C4 south=C8
+C5 north=C1 south=C9
+C7 east=C8
+C8 north=C4 west=C7
+C9 north=C5
+`, string(g.Generate()))
+
+	assert.Empty(t, NewMaskGenerator().Generate())
+}
+
+func TestMask_KeepConnected(t *testing.T) {
+	mask := []string{
+		"#####...####",
+		"#####...####",
+		"#####...####",
+		"############",
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		opts := []Option{WithMask(mask), WithSeed(seed), WithRoadRemoval(0.5), WithKeepConnected()}
+		wm := parseMap(t, NewMaskGenerator(opts...).Generate())
+		require.NoError(t, wm.Validate())
+		assert.Len(t, wm.Stats().Components, 1)
+		// the cells out of the mask are never restored
+		for _, name := range []string{"C6", "C7", "C8", "C18", "C19", "C20", "C30", "C31", "C32"} {
+			assert.False(t, wm.HasCity(name), name)
+		}
+	}
+}
+
+func TestMask_Read(t *testing.T) {
+	rows, err := ReadMask(strings.NewReader("##.#\r\n# ##\n#\n\n  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"##.#", "# ##", "#"}, rows)
+}
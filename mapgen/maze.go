@@ -0,0 +1,190 @@
+package mapgen
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// MazeAlgorithm defines the algorithm used to carve a maze in the grid.
+type MazeAlgorithm int
+
+// maze algorithm options
+const (
+	// NoMaze keeps the roads of the grid. This is the default.
+	NoMaze MazeAlgorithm = iota
+	// MazeBacktracker carves the maze with a randomized depth-first search,
+	// which makes long and winding corridors with few dead ends.
+	MazeBacktracker
+	// MazePrim carves the maze with the randomized Prim algorithm, which
+	// makes short corridors and many dead ends.
+	MazePrim
+	// MazeKruskal carves the maze with the randomized Kruskal algorithm,
+	// which makes a uniform looking maze.
+	MazeKruskal
+)
+
+// String implements fmt.Stringer.
+func (a MazeAlgorithm) String() string {
+	switch a {
+	case NoMaze:
+		return "none"
+	case MazeBacktracker:
+		return "backtracker"
+	case MazePrim:
+		return "prim"
+	case MazeKruskal:
+		return "kruskal"
+	}
+	return "invalid maze algorithm"
+}
+
+// ParseMazeAlgorithm converts an algorithm name ("none", "backtracker",
+// "prim" or "kruskal") into a MazeAlgorithm. If the name is not valid this
+// will return an error.
+func ParseMazeAlgorithm(s string) (MazeAlgorithm, error) {
+	switch s {
+	case "none":
+		return NoMaze, nil
+	case "backtracker":
+		return MazeBacktracker, nil
+	case "prim":
+		return MazePrim, nil
+	case "kruskal":
+		return MazeKruskal, nil
+	}
+	return -1, fmt.Errorf("%s is not a valid maze algorithm", s)
+}
+
+// WithMaze makes the generator carve a perfect maze in the grid: a spanning
+// tree of the cities, so there is exactly one path between any two of
+// them. The road removal probability is ignored (CheckOptions rejects it),
+// but cities can still be removed; then each part of the grid gets its own
+// maze, unless the map is kept connected.
+func WithMaze(alg MazeAlgorithm) Option {
+	return func(o *options) {
+		o.maze = alg
+		o.use("maze")
+	}
+}
+
+// WithLoops braids the maze, restoring each of its removed roads with the
+// given probability, between 0 and 1. Each restored road makes a loop. It
+// is ignored without WithMaze (CheckOptions rejects it).
+func WithLoops(p float64) Option {
+	return func(o *options) {
+		o.loops = p
+		o.use("loops")
+	}
+}
+
+// carveMaze opens the roads of a spanning tree of each part of the grid.
+// All the roads must be removed before.
+func (g *GridGenerator) carveMaze(rnd *rand.Rand) {
+	switch g.opts.maze {
+	case MazeBacktracker:
+		g.carveBacktracker(rnd)
+	case MazePrim:
+		g.carvePrim(rnd)
+	case MazeKruskal:
+		g.carveKruskal(rnd)
+	}
+}
+
+// braid restores each removed road between remaining cities with the loop
+// probability.
+func (g *GridGenerator) braid(rnd *rand.Rand) {
+	for _, r := range g.gridRoads() {
+		a, b := g.roadEnds(r)
+		if !g.roadRemoved(r) || g.removedCities[a] || g.removedCities[b] {
+			continue
+		}
+		if rnd.Float64() < g.opts.loops {
+			g.setRoadRemoved(r, false)
+		}
+	}
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// carveBacktracker carves the maze with an iterative randomized depth-first
+// search.
+func (g *GridGenerator) carveBacktracker(rnd *rand.Rand) {
+	visited := make([]bool, len(g.removedCities))
+	for start := range visited {
+		if visited[start] || g.removedCities[start] {
+			continue
+		}
+		visited[start] = true
+		stack := []int{start}
+		for len(stack) > 0 {
+			k := stack[len(stack)-1]
+			var next []int
+			for _, r := range g.cityRoads(k) {
+				if o := g.otherEnd(r, k); !visited[o] && !g.removedCities[o] {
+					next = append(next, r)
+				}
+			}
+			if len(next) == 0 {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			r := next[rnd.Intn(len(next))]
+			o := g.otherEnd(r, k)
+			g.setRoadRemoved(r, false)
+			visited[o] = true
+			stack = append(stack, o)
+		}
+	}
+}
+
+// carvePrim carves the maze with the randomized Prim algorithm.
+//
+func (g *GridGenerator) carvePrim(rnd *rand.Rand) {
+	visited := make([]bool, len(g.removedCities))
+	for start := range visited {
+		if visited[start] || g.removedCities[start] {
+			continue
+		}
+		visited[start] = true
+		frontier := g.cityRoads(start)
+		for len(frontier) > 0 {
+			i := rnd.Intn(len(frontier))
+			r := frontier[i]
+			frontier[i] = frontier[len(frontier)-1]
+			frontier = frontier[:len(frontier)-1]
+
+			a, b := g.roadEnds(r)
+			o := a
+			if visited[a] {
+				o = b
+			}
+			if visited[o] || g.removedCities[o] {
+				continue
+			}
+			g.setRoadRemoved(r, false)
+			visited[o] = true
+			frontier = append(frontier, g.cityRoads(o)...)
+		}
+	}
+}
+
+// carveKruskal carves the maze with the randomized Kruskal algorithm.
+//
+func (g *GridGenerator) carveKruskal(rnd *rand.Rand) {
+	roads := g.gridRoads()
+	rnd.Shuffle(len(roads), func(i, j int) {
+		roads[i], roads[j] = roads[j], roads[i]
+	})
+	uf := newUnionFind(len(g.removedCities))
+	for _, r := range roads {
+		a, b := g.roadEnds(r)
+		if g.removedCities[a] || g.removedCities[b] {
+			continue
+		}
+		if uf.union(a, b) {
+			g.setRoadRemoved(r, false)
+		}
+	}
+}
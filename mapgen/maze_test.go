@@ -0,0 +1,69 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMazeAlgorithm_Parse(t *testing.T) {
+	for _, alg := range []MazeAlgorithm{NoMaze, MazeBacktracker, MazePrim, MazeKruskal} {
+		parsed, err := ParseMazeAlgorithm(alg.String())
+		require.NoError(t, err)
+		assert.Equal(t, alg, parsed)
+	}
+	_, err := ParseMazeAlgorithm("eller")
+	assert.EqualError(t, err, "eller is not a valid maze algorithm")
+}
+
+func TestMaze_Perfect(t *testing.T) {
+	for _, alg := range []MazeAlgorithm{MazeBacktracker, MazePrim, MazeKruskal} {
+		t.Run(alg.String(), func(t *testing.T) {
+			for seed := int64(0); seed < 5; seed++ {
+				data := NewGridGenerator(12, 9, WithSeed(seed), WithMaze(alg)).Generate()
+				wm := parseMap(t, data)
+				require.NoError(t, wm.Validate())
+
+				// a spanning tree of the grid
+				assert.Equal(t, 12*9, wm.NumCities())
+				assert.Len(t, wm.Roads(), 12*9-1)
+				assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 1)
+			}
+
+			// the same seed generates the same maze
+			assert.Equal(t, NewGridGenerator(8, 8, WithSeed(3), WithMaze(alg)).Generate(), NewGridGenerator(8, 8, WithSeed(3), WithMaze(alg)).Generate())
+		})
+	}
+}
+
+func TestMaze_RemovedCities(t *testing.T) {
+	for _, alg := range []MazeAlgorithm{MazeBacktracker, MazePrim, MazeKruskal} {
+		// each part of the grid gets its own maze: a forest
+		data := NewGridGenerator(12, 9, WithSeed(1), WithMaze(alg), WithCityRemoval(0.3), WithRoadRemoval(0.9)).Generate()
+		wm := parseMap(t, data)
+		require.NoError(t, wm.Validate())
+		components := wm.Stats(invasion.WithoutDiameter()).Components
+		assert.Len(t, wm.Roads(), wm.NumCities()-len(components), alg.String())
+
+		// unless the map is kept connected: a tree
+		data = NewGridGenerator(12, 9, WithSeed(1), WithMaze(alg), WithCityRemoval(0.3), WithKeepConnected()).Generate()
+		wm = parseMap(t, data)
+		require.NoError(t, wm.Validate())
+		assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 1, alg.String())
+		assert.Len(t, wm.Roads(), wm.NumCities()-1, alg.String())
+	}
+}
+
+func TestMaze_Loops(t *testing.T) {
+	perfect := parseMap(t, NewGridGenerator(12, 9, WithSeed(2), WithMaze(MazeKruskal)).Generate())
+	braided := parseMap(t, NewGridGenerator(12, 9, WithSeed(2), WithMaze(MazeKruskal), WithLoops(0.3)).Generate())
+	require.NoError(t, braided.Validate())
+	assert.Greater(t, len(braided.Roads()), len(perfect.Roads()))
+	assert.Less(t, len(braided.Roads()), 2*12*9-12-9)
+
+	// restoring every removed road gives the complete grid back
+	data := NewGridGenerator(6, 4, WithSeed(2), WithMaze(MazePrim), WithLoops(1)).Generate()
+	assert.Equal(t, NewGridGenerator(6, 4).Generate(), data)
+}
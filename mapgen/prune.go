@@ -0,0 +1,184 @@
+package mapgen
+
+import (
+	"math/rand"
+)
+
+// prune removes roads and cities of the grid randomly, using the
+// probabilities of the generator, or carves a maze. If the map must be kept
+// connected, some of them are restored afterwards.
+func (g *GridGenerator) prune() {
+	g.removedCities, g.removedEast, g.removedSouth = nil, nil, nil
+	if g.opts.roadRemoval <= 0 && g.opts.cityRemoval <= 0 && g.opts.maze == NoMaze && g.cells == nil {
+		return
+	}
+
+	n := g.width * g.height
+	rnd := rand.New(rand.NewSource(g.opts.seed))
+	g.removedCities = make([]bool, n)
+	g.removedEast = make([]bool, n)
+	g.removedSouth = make([]bool, n)
+	for k := 0; k < n; k++ {
+		if g.cells != nil && !g.cells[k] {
+			g.removedCities[k] = true
+			continue
+		}
+		g.removedCities[k] = rnd.Float64() < g.opts.cityRemoval
+	}
+	if g.opts.maze == NoMaze {
+		for k := 0; k < n; k++ {
+			g.removedEast[k] = rnd.Float64() < g.opts.roadRemoval
+			g.removedSouth[k] = rnd.Float64() < g.opts.roadRemoval
+		}
+	} else {
+		for k := 0; k < n; k++ {
+			g.removedEast[k], g.removedSouth[k] = true, true
+		}
+	}
+
+	// the roads of the removed cities are removed too, so restoring a city
+	// only restores the roads needed to connect it
+	for k := 0; k < n; k++ {
+		if !g.removedCities[k] {
+			continue
+		}
+		for _, r := range g.cityRoads(k) {
+			g.setRoadRemoved(r, true)
+		}
+	}
+
+	if g.opts.maze != NoMaze {
+		g.carveMaze(rnd)
+	}
+	if g.opts.keepConnected {
+		g.reconnect(rnd)
+	}
+	if g.opts.maze != NoMaze && g.opts.loops > 0 {
+		g.braid(rnd)
+	}
+}
+
+// reconnect restores roads and cities until the remaining cities are
+// connected. It builds a random spanning forest of the grid with the
+// Kruskal algorithm, preferring the roads that were not removed, then the
+// removed roads between remaining cities and finally the roads through
+// removed cities. The removed cities that do not connect anything are not
+// restored.
+func (g *GridGenerator) reconnect(rnd *rand.Rand) {
+	roads := g.gridRoads()
+	rnd.Shuffle(len(roads), func(i, j int) {
+		roads[i], roads[j] = roads[j], roads[i]
+	})
+
+	uf := newUnionFind(g.width * g.height)
+	for _, r := range roads {
+		if !g.roadRemoved(r) {
+			a, b := g.roadEnds(r)
+			uf.union(a, b)
+		}
+	}
+	for _, r := range roads {
+		a, b := g.roadEnds(r)
+		if g.roadRemoved(r) && !g.removedCities[a] && !g.removedCities[b] && uf.union(a, b) {
+			g.setRoadRemoved(r, false)
+		}
+	}
+
+	// roads through removed cities that join different parts of the map,
+	// the cells without cities are never crossed
+	var through []int
+	adj := make(map[int][]int)
+	for _, r := range roads {
+		a, b := g.roadEnds(r)
+		if g.cells != nil && (!g.cells[a] || !g.cells[b]) {
+			continue
+		}
+		if (g.removedCities[a] || g.removedCities[b]) && uf.union(a, b) {
+			adj[a] = append(adj[a], len(through))
+			adj[b] = append(adj[b], len(through))
+			through = append(through, r)
+		}
+	}
+
+	// drop the removed cities at the leaves of the spanning forest until
+	// every removed city left connects at least two parts
+	dropped := make([]bool, len(through))
+	degree := make(map[int]int, len(adj))
+	var leaves []int
+	for k, rs := range adj {
+		degree[k] = len(rs)
+		if g.removedCities[k] && len(rs) == 1 {
+			leaves = append(leaves, k)
+		}
+	}
+	for len(leaves) > 0 {
+		k := leaves[len(leaves)-1]
+		leaves = leaves[:len(leaves)-1]
+		for _, i := range adj[k] {
+			if dropped[i] {
+				continue
+			}
+			dropped[i] = true
+			degree[k]--
+			a, b := g.roadEnds(through[i])
+			other := a
+			if other == k {
+				other = b
+			}
+			degree[other]--
+			if g.removedCities[other] && degree[other] == 1 {
+				leaves = append(leaves, other)
+			}
+		}
+	}
+
+	for i, r := range through {
+		if dropped[i] {
+			continue
+		}
+		a, b := g.roadEnds(r)
+		g.removedCities[a], g.removedCities[b] = false, false
+		g.setRoadRemoved(r, false)
+	}
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// unionFind is a disjoint set of integers.
+//
+type unionFind struct {
+	parent []int
+}
+
+// newUnionFind creates a disjoint set with n integers, each one in its own
+// set.
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parent: make([]int, n)}
+	for i := range uf.parent {
+		uf.parent[i] = i
+	}
+	return uf
+}
+
+// find returns the representative of the set of x.
+//
+func (uf *unionFind) find(x int) int {
+	for uf.parent[x] != x {
+		uf.parent[x] = uf.parent[uf.parent[x]]
+		x = uf.parent[x]
+	}
+	return x
+}
+
+// union joins the sets of a and b. It reports whether they were different
+// sets.
+func (uf *unionFind) union(a, b int) bool {
+	ra, rb := uf.find(a), uf.find(b)
+	if ra == rb {
+		return false
+	}
+	uf.parent[ra] = rb
+	return true
+}
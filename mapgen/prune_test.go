@@ -0,0 +1,67 @@
+package mapgen
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune_Seed(t *testing.T) {
+	opts := []Option{WithSeed(7), WithRoadRemoval(0.3), WithCityRemoval(0.2)}
+	data := NewGridGenerator(10, 10, opts...).Generate()
+
+	// the same seed generates the same map
+	assert.Equal(t, data, NewGridGenerator(10, 10, opts...).Generate())
+	// a different seed generates a different map
+	assert.NotEqual(t, data, NewGridGenerator(10, 10, WithSeed(8), WithRoadRemoval(0.3), WithCityRemoval(0.2)).Generate())
+	// and no removal generates the complete grid
+	assert.Equal(t, NewGridGenerator(10, 10).Generate(), NewGridGenerator(10, 10, WithSeed(7)).Generate())
+}
+
+func TestPrune_Consistency(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		data := NewGridGenerator(15, 12, WithSeed(seed), WithRoadRemoval(0.4), WithCityRemoval(0.3)).Generate()
+		wm := parseMap(t, data)
+		require.NoError(t, wm.Validate())
+		assert.Less(t, wm.NumCities(), 15*12)
+		assert.Less(t, len(wm.Roads()), 2*15*12-15-12)
+	}
+
+	// removing everything
+	data := NewGridGenerator(5, 5, WithCityRemoval(1)).Generate()
+	assert.Empty(t, data)
+	data = NewGridGenerator(5, 5, WithRoadRemoval(1)).Generate()
+	wm := parseMap(t, data)
+	assert.Equal(t, 25, wm.NumCities())
+	assert.Empty(t, wm.Roads())
+}
+
+func TestPrune_KeepConnected(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		data := NewGridGenerator(20, 15, WithSeed(seed), WithRoadRemoval(0.6), WithCityRemoval(0.4), WithKeepConnected()).Generate()
+		wm := parseMap(t, data)
+		require.NoError(t, wm.Validate())
+		stats := wm.Stats(invasion.WithoutDiameter())
+		assert.Len(t, stats.Components, 1, "seed %d", seed)
+		assert.Less(t, wm.NumCities(), 20*15)
+	}
+
+	// only removing roads keeps all the cities, joined by a spanning tree
+	// at least
+	data := NewGridGenerator(10, 10, WithRoadRemoval(1), WithKeepConnected()).Generate()
+	wm := parseMap(t, data)
+	assert.Equal(t, 100, wm.NumCities())
+	assert.Len(t, wm.Roads(), 99)
+	assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 1)
+}
+
+func parseMap(t *testing.T, data []byte) *invasion.WorldMap {
+	t.Helper()
+	wm, err := invasion.ParseWorldMap(bufio.NewScanner(bytes.NewReader(data)), invasion.WithDuplicatePolicy(invasion.DuplicateError))
+	require.NoError(t, err)
+	return wm
+}
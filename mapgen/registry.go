@@ -0,0 +1,176 @@
+package mapgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type represents a kind of generated map.
+type Type struct {
+	// Name identifies the type, e.g. "grid" or "maze".
+	Name string
+	// Description explains the maps of the type in a few words.
+	Description string
+	// Options has the names of the options used by the type, e.g. "seed"
+	// or "road-removal", as named in the map_generator flags. New rejects
+	// the other options.
+	Options []string
+	// Defaults has the options applied before the ones passed to New. The
+	// options of Defaults missing in Options cannot be changed.
+	Defaults []Option
+	// New creates a generator of the type. Types without a height, like
+	// "line", ignore it.
+	New func(width, height int, opts ...Option) Generator
+}
+
+// gridOptions has the names of the options used by GridGenerator.
+var gridOptions = []string{"seed", "road-removal", "city-removal", "keep-connected", "maze", "loops", "wrap"}
+
+// newGrid creates a GridGenerator as a Generator.
+//
+func newGrid(width, height int, opts ...Option) Generator {
+	return NewGridGenerator(width, height, opts...)
+}
+
+// types has the registered generator types, in registration order.
+var types = []*Type{
+	{
+		Name:        "grid",
+		Description: "rectangular grid of cities",
+		Options:     gridOptions,
+		New:         newGrid,
+	},
+	{
+		Name:        "pruned",
+		Description: "grid with roads removed randomly (30% by default)",
+		Options:     []string{"seed", "road-removal", "city-removal", "keep-connected", "wrap"},
+		Defaults:    []Option{WithRoadRemoval(0.3)},
+		New:         newGrid,
+	},
+	{
+		Name:        "maze",
+		Description: "grid with a perfect maze (backtracker by default)",
+		Options:     []string{"seed", "city-removal", "keep-connected", "maze", "loops", "wrap"},
+		Defaults:    []Option{WithMaze(MazeBacktracker)},
+		New:         newGrid,
+	},
+	{
+		Name:        "torus",
+		Description: "grid with both pairs of edges joined",
+		Options:     []string{"seed", "road-removal", "city-removal", "keep-connected", "maze", "loops"},
+		Defaults:    []Option{WithWrap(WrapTorus)},
+		New:         newGrid,
+	},
+	{
+		Name:        "islands",
+		Description: "grid split in islands without roads between them",
+		Options:     []string{"seed", "islands", "land-ratio", "road-removal", "city-removal", "keep-connected", "maze", "loops"},
+		New: func(width, height int, opts ...Option) Generator {
+			return NewIslandsGenerator(width, height, opts...)
+		},
+	},
+	{
+		Name:        "spiral",
+		Description: "single road winding to the center of the grid",
+		Options:     []string{"seed", "cross-links"},
+		New: func(width, height int, opts ...Option) Generator {
+			return NewSpiralGenerator(width, height, opts...)
+		},
+	},
+	{
+		Name:        "line",
+		Description: "cities in a row as long as the width",
+		Options:     []string{"seed", "branches"},
+		New: func(width, _ int, opts ...Option) Generator {
+			return NewLineGenerator(width, opts...)
+		},
+	},
+	{
+		Name:        "hubs",
+		Description: "cross shaped hubs joined by their arms",
+		Options:     []string{"seed", "arm-length", "hub-links"},
+		New: func(width, height int, opts ...Option) Generator {
+			return NewHubGenerator(width, height, opts...)
+		},
+	},
+	{
+		Name:        "mask",
+		Description: "cities placed by a mask, ignoring the width and height",
+		Options:     append([]string{"mask"}, gridOptions...),
+		New: func(_, _ int, opts ...Option) Generator {
+			return NewMaskGenerator(opts...)
+		},
+	},
+}
+
+// RegisterType registers a new generator type. The name cannot be already
+// registered.
+func RegisterType(t Type) error {
+	if t.Name == "" || t.New == nil {
+		return fmt.Errorf("generator type must have a name and a new function")
+	}
+	if _, ok := LookupType(t.Name); ok {
+		return fmt.Errorf("generator type %s is already registered", t.Name)
+	}
+	types = append(types, &t)
+	return nil
+}
+
+// LookupType returns the generator type registered with the given name.
+//
+func LookupType(name string) (*Type, bool) {
+	for _, t := range types {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// TypeNames returns the names of the registered generator types.
+//
+func TypeNames() []string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// New creates a generator of the type registered with the given name. It
+// returns an error if the width or the height is negative, if an option is
+// not used by the type, or if the options are not valid (see CheckOptions).
+func New(name string, width, height int, opts ...Option) (Generator, error) {
+	t, ok := LookupType(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown generator type %q, valid types are: %s", name, strings.Join(TypeNames(), ", "))
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("the width and the height cannot be negative, got %dx%d", width, height)
+	}
+	for _, used := range newOptions(opts).used {
+		if !t.uses(used) {
+			return nil, fmt.Errorf("the %s generator type does not use the %s option", t.Name, used)
+		}
+	}
+	opts = append(append([]Option{}, t.Defaults...), opts...)
+	if err := CheckOptions(opts...); err != nil {
+		return nil, err
+	}
+	return t.New(width, height, opts...), nil
+}
+
+// ===============================================================
+// Utils
+// ===============================================================
+
+// uses reports whether the type uses the option with the given name.
+//
+func (t *Type) uses(option string) bool {
+	for _, o := range t.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
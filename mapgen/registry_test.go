@@ -0,0 +1,99 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_New(t *testing.T) {
+	assert.Equal(t, []string{"grid", "pruned", "maze", "torus", "islands", "spiral", "line", "hubs", "mask"}, TypeNames())
+
+	for _, name := range TypeNames() {
+		opts := []Option{WithSeed(1)}
+		if name == "mask" {
+			opts = append(opts, WithMask([]string{"###", "#.#"}))
+		}
+		g, err := New(name, 8, 6, opts...)
+		require.NoError(t, err, name)
+		wm := parseMap(t, g.Generate())
+		require.NoError(t, wm.Validate(), name)
+		assert.NotZero(t, wm.NumCities(), name)
+	}
+
+	_, err := New("hexagon", 8, 6)
+	assert.EqualError(t, err, `unknown generator type "hexagon", valid types are: grid, pruned, maze, torus, islands, spiral, line, hubs, mask`)
+
+	_, err = New("grid", -1, 6)
+	assert.EqualError(t, err, "the width and the height cannot be negative, got -1x6")
+	_, err = New("line", 8, -6)
+	assert.EqualError(t, err, "the width and the height cannot be negative, got 8x-6")
+	_, err = New("islands", 8, 6, WithIslands(0))
+	assert.EqualError(t, err, "the number of islands must be at least 1, got 0")
+	g, err := New("grid", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, g.Generate())
+}
+
+func TestRegistry_Defaults(t *testing.T) {
+	newMap := func(name string, opts ...Option) *invasion.WorldMap {
+		g, err := New(name, 8, 6, opts...)
+		require.NoError(t, err)
+		return parseMap(t, g.Generate())
+	}
+	grid := newMap("grid")
+
+	// pruned removes 30% of the roads by default
+	assert.Less(t, len(newMap("pruned").Roads()), len(grid.Roads()))
+	assert.Equal(t, grid, newMap("pruned", WithRoadRemoval(0)))
+	assert.Less(t, newMap("pruned", WithCityRemoval(0.5)).NumCities(), grid.NumCities())
+	// maze carves a perfect maze
+	assert.Len(t, newMap("maze").Roads(), 8*6-1)
+	assert.Equal(t, newMap("maze", WithMaze(MazePrim)), parseMap(t, NewGridGenerator(8, 6, WithMaze(MazePrim)).Generate()))
+	// torus joins the edges
+	assert.Len(t, newMap("torus").Roads(), 2*8*6)
+	// line ignores the height
+	assert.Equal(t, 8, newMap("line").NumCities())
+}
+
+func TestRegistry_Options(t *testing.T) {
+	// the options that a type does not use are rejected
+	_, err := New("spiral", 8, 6, WithSeed(1), WithMaze(MazeKruskal), WithRoadRemoval(0.9))
+	assert.EqualError(t, err, "the spiral generator type does not use the maze option")
+	_, err = New("torus", 8, 6, WithWrap(WrapMobius))
+	assert.EqualError(t, err, "the torus generator type does not use the wrap option")
+	_, err = New("maze", 8, 6, WithRoadRemoval(0.3))
+	assert.EqualError(t, err, "the maze generator type does not use the road-removal option")
+	_, err = New("line", 8, 6, WithMask([]string{"#"}))
+	assert.EqualError(t, err, "the line generator type does not use the mask option")
+
+	// and so are the options that cannot be used together
+	_, err = New("grid", 8, 6, WithLoops(0.1))
+	assert.EqualError(t, err, "the loop probability can only be used with a maze")
+	_, err = New("maze", 8, 6, WithLoops(0.1))
+	assert.NoError(t, err)
+
+	for _, typ := range types {
+		for _, name := range typ.Options {
+			assert.Contains(t, []string{"seed", "road-removal", "city-removal", "keep-connected", "maze", "loops", "wrap",
+				"mask", "islands", "land-ratio", "cross-links", "branches", "arm-length", "hub-links"}, name, typ.Name)
+		}
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	defer func(saved []*Type) { types = saved }(append([]*Type(nil), types...))
+
+	err := RegisterType(Type{Name: "single", New: func(_, _ int, opts ...Option) Generator {
+		return NewLineGenerator(1, opts...)
+	}})
+	require.NoError(t, err)
+	g, err := New("single", 8, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "C1\n", string(g.Generate()))
+
+	assert.EqualError(t, RegisterType(Type{Name: "grid", New: types[0].New}), "generator type grid is already registered")
+	assert.EqualError(t, RegisterType(Type{Name: "empty"}), "generator type must have a name and a new function")
+}
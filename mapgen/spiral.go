@@ -0,0 +1,75 @@
+package mapgen
+
+import (
+	"math/rand"
+)
+
+// WithCrossLinks adds roads between the neighbouring cities of the spiral
+// of SpiralGenerator that are not joined by it, each one with the given
+// probability, between 0 and 1.
+func WithCrossLinks(p float64) Option {
+	return func(o *options) {
+		o.crossLinks = p
+		o.use("cross-links")
+	}
+}
+
+// SpiralGenerator generates maps with a single road that winds from the
+// north west corner of the grid to its center, clockwise. It uses the
+// options WithSeed and WithCrossLinks.
+type SpiralGenerator struct {
+	width, height int
+	opts          options
+}
+
+// NewSpiralGenerator creates a new spiral map generator instance.
+//
+func NewSpiralGenerator(width, height int, opts ...Option) *SpiralGenerator {
+	return &SpiralGenerator{width: width, height: height, opts: *newOptions(opts)}
+}
+
+// Generate generates a world map with a spiral.
+//
+func (g *SpiralGenerator) Generate() []byte {
+	gr := newEmptyGrid(g.width, g.height)
+	pi, pj := -1, -1
+	visit := func(i, j int) {
+		if pi < 0 {
+			gr.addCity(i, j)
+		} else {
+			gr.connect(pi, pj, i, j)
+		}
+		pi, pj = i, j
+	}
+
+	x0, y0, x1, y1 := 0, 0, g.width-1, g.height-1
+	for x0 <= x1 && y0 <= y1 {
+		for i := x0; i <= x1; i++ {
+			visit(i, y0)
+		}
+		for j := y0 + 1; j <= y1; j++ {
+			visit(x1, j)
+		}
+		if y0 < y1 {
+			for i := x1 - 1; i >= x0; i-- {
+				visit(i, y1)
+			}
+		}
+		if x0 < x1 {
+			for j := y1 - 1; j > y0; j-- {
+				visit(x0, j)
+			}
+		}
+		x0, y0, x1, y1 = x0+1, y0+1, x1-1, y1-1
+	}
+
+	if g.opts.crossLinks > 0 {
+		rnd := rand.New(rand.NewSource(g.opts.seed))
+		for _, r := range gr.gridRoads() {
+			if gr.roadRemoved(r) && rnd.Float64() < g.opts.crossLinks {
+				gr.setRoadRemoved(r, false)
+			}
+		}
+	}
+	return gr.write()
+}
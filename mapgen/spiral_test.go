@@ -0,0 +1,49 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpiral_Generate(t *testing.T) {
+	g := NewSpiralGenerator(4, 3)
+	assert.Equal(t, `C1 east=C2
+C2 east=C3 west=C1
+C3 east=C4 west=C2
+C4 south=C8 west=C3
+C5 south=C9 east=C6
+C6 east=C7 west=C5
+C7 west=C6
+C8 north=C4 south=C12
+C9 north=C5 east=C10
+C10 east=C11 west=C9
+C11 east=C12 west=C10
+C12 north=C8 west=C11
+`, string(g.Generate()))
+
+	// a single road visits every city
+	for _, size := range [][2]int{{1, 1}, {1, 5}, {5, 1}, {6, 6}, {7, 4}} {
+		wm := parseMap(t, NewSpiralGenerator(size[0], size[1]).Generate())
+		require.NoError(t, wm.Validate())
+		assert.Equal(t, size[0]*size[1], wm.NumCities())
+		assert.Len(t, wm.Roads(), size[0]*size[1]-1)
+		assert.Len(t, wm.Stats().Components, 1)
+	}
+}
+
+func TestSpiral_CrossLinks(t *testing.T) {
+	spiral := parseMap(t, NewSpiralGenerator(6, 6).Generate())
+	data := NewSpiralGenerator(6, 6, WithSeed(3), WithCrossLinks(0.5)).Generate()
+	assert.Equal(t, data, NewSpiralGenerator(6, 6, WithSeed(3), WithCrossLinks(0.5)).Generate())
+
+	wm := parseMap(t, data)
+	require.NoError(t, wm.Validate())
+	assert.Greater(t, len(wm.Roads()), len(spiral.Roads()))
+	assert.Less(t, len(wm.Roads()), 2*6*6-6-6)
+
+	// all the roads of the grid
+	wm = parseMap(t, NewSpiralGenerator(6, 6, WithCrossLinks(1)).Generate())
+	assert.Len(t, wm.Roads(), 2*6*6-6-6)
+}
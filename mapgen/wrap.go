@@ -0,0 +1,66 @@
+package mapgen
+
+import (
+	"fmt"
+)
+
+// Wrap defines how the edges of the grid are joined.
+type Wrap int
+
+// wrap options
+const (
+	// NoWrap keeps the edges of the grid apart. This is the default.
+	NoWrap Wrap = iota
+	// WrapCylinder joins the east edge of the grid to its west edge, row by
+	// row.
+	WrapCylinder
+	// WrapTorus joins the east edge of the grid to its west edge, and the
+	// south edge to the north edge, column by column.
+	WrapTorus
+	// WrapMobius joins the east edge of the grid to its west edge with a
+	// twist: the first row is joined to the last one, the second row to the
+	// second last one, and so on.
+	WrapMobius
+)
+
+// String implements fmt.Stringer.
+func (w Wrap) String() string {
+	switch w {
+	case NoWrap:
+		return "none"
+	case WrapCylinder:
+		return "cylinder"
+	case WrapTorus:
+		return "torus"
+	case WrapMobius:
+		return "mobius"
+	}
+	return "invalid wrap"
+}
+
+// ParseWrap converts a wrap name ("none", "cylinder", "torus" or "mobius")
+// into a Wrap. If the name is not valid this will return an error.
+func ParseWrap(s string) (Wrap, error) {
+	switch s {
+	case "none":
+		return NoWrap, nil
+	case "cylinder":
+		return WrapCylinder, nil
+	case "torus":
+		return WrapTorus, nil
+	case "mobius":
+		return WrapMobius, nil
+	}
+	return -1, fmt.Errorf("%s is not a valid wrap", s)
+}
+
+// WithWrap joins the edges of the grid, so the cities at the edges are not
+// dead ends. An edge is only joined if the grid has at least 3 cities
+// across it, so two cities are never linked by more than one road. Roads
+// and cities can still be removed, and mazes use the joined edges too.
+func WithWrap(w Wrap) Option {
+	return func(o *options) {
+		o.wrap = w
+		o.use("wrap")
+	}
+}
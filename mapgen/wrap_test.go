@@ -0,0 +1,86 @@
+package mapgen
+
+import (
+	"testing"
+
+	"github.com/fpabl0/saga-alien-invasion/invasion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap_Parse(t *testing.T) {
+	for _, w := range []Wrap{NoWrap, WrapCylinder, WrapTorus, WrapMobius} {
+		parsed, err := ParseWrap(w.String())
+		require.NoError(t, err)
+		assert.Equal(t, w, parsed)
+	}
+	_, err := ParseWrap("sphere")
+	assert.EqualError(t, err, "sphere is not a valid wrap")
+}
+
+func TestWrap_Cylinder(t *testing.T) {
+	g := NewGridGenerator(4, 3, WithWrap(WrapCylinder))
+	assert.Equal(t, `C1 south=C5 east=C2 west=C4
+C2 south=C6 east=C3 west=C1
+C3 south=C7 east=C4 west=C2
+C4 south=C8 east=C1 west=C3
+C5 north=C1 south=C9 east=C6 west=C8
+C6 north=C2 south=C10 east=C7 west=C5
+C7 north=C3 south=C11 east=C8 west=C6
+C8 north=C4 south=C12 east=C5 west=C7
+C9 north=C5 east=C10 west=C12
+C10 north=C6 east=C11 west=C9
+C11 north=C7 east=C12 west=C10
+C12 north=C8 east=C9 west=C11
+`, string(g.Generate()))
+}
+
+func TestWrap_Torus(t *testing.T) {
+	wm := parseMap(t, NewGridGenerator(5, 4, WithWrap(WrapTorus)).Generate())
+	require.NoError(t, wm.Validate())
+	assert.Len(t, wm.Roads(), 2*5*4)
+
+	// there are no dead ends nor borders
+	stats := wm.Stats(invasion.WithoutDiameter())
+	assert.Equal(t, [5]int{0, 0, 0, 0, 20}, stats.DegreeHistogram)
+	assert.Equal(t, "C17", wm.Neighbours("C2")[invasion.North])
+	assert.Equal(t, "C5", wm.Neighbours("C1")[invasion.West])
+	assert.Equal(t, "C1", wm.Neighbours("C16")[invasion.South])
+}
+
+func TestWrap_Mobius(t *testing.T) {
+	wm := parseMap(t, NewGridGenerator(4, 3, WithWrap(WrapMobius)).Generate())
+	require.NoError(t, wm.Validate())
+
+	// the first row is joined to the last one
+	assert.Equal(t, "C9", wm.Neighbours("C4")[invasion.East])
+	assert.Equal(t, "C4", wm.Neighbours("C9")[invasion.West])
+	assert.Equal(t, "C1", wm.Neighbours("C12")[invasion.East])
+	// and the middle row to itself
+	assert.Equal(t, "C5", wm.Neighbours("C8")[invasion.East])
+	// the north and south edges are not joined
+	assert.Empty(t, wm.Neighbours("C1")[invasion.North])
+}
+
+func TestWrap_SmallGrids(t *testing.T) {
+	// edges with less than 3 cities across are not joined
+	assert.Equal(t, NewGridGenerator(2, 2).Generate(), NewGridGenerator(2, 2, WithWrap(WrapTorus)).Generate())
+
+	wm := parseMap(t, NewGridGenerator(2, 3, WithWrap(WrapTorus)).Generate())
+	require.NoError(t, wm.Validate())
+	assert.Equal(t, "C5", wm.Neighbours("C1")[invasion.North])
+	assert.Empty(t, wm.Neighbours("C1")[invasion.West])
+}
+
+func TestWrap_MazeAndPruning(t *testing.T) {
+	for _, w := range []Wrap{WrapCylinder, WrapTorus, WrapMobius} {
+		wm := parseMap(t, NewGridGenerator(9, 7, WithWrap(w), WithSeed(4), WithMaze(MazeBacktracker)).Generate())
+		require.NoError(t, wm.Validate(), w.String())
+		assert.Len(t, wm.Roads(), 9*7-1, w.String())
+		assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 1, w.String())
+
+		wm = parseMap(t, NewGridGenerator(9, 7, WithWrap(w), WithSeed(4), WithRoadRemoval(0.5), WithCityRemoval(0.3), WithKeepConnected()).Generate())
+		require.NoError(t, wm.Validate(), w.String())
+		assert.Len(t, wm.Stats(invasion.WithoutDiameter()).Components, 1, w.String())
+	}
+}